@@ -0,0 +1,305 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SessionCookie is the cookie that holds the session of a logged in player
+const SessionCookie = "wotd_session"
+
+// maxRequestBytes limits the size of a request body
+const maxRequestBytes = 4096
+
+var (
+	// ErrNotLoggedIn is returned when a request has no session cookie
+	ErrNotLoggedIn = errors.New("not logged in")
+	// ErrSessionExpired is returned when a request's session cookie is unknown or has expired
+	ErrSessionExpired = errors.New("your session has expired, log in again")
+	// ErrInvalidToken is returned when logging in with a magic token that is unknown, used or expired
+	ErrInvalidToken = errors.New("the token is invalid or has expired")
+)
+
+// Handler serves the account HTTP API. It expects to be mounted with the prefix stripped, eg
+// http.StripPrefix("/account", handler), and serves:
+//
+//	POST /register   creates an account from a Registration and logs in
+//	POST /login      logs in with a Login, by username and password or by magic token
+//	POST /logout     logs out
+//	GET  /me         returns the logged in account's Profile
+//	PUT  /me         changes the logged in account's preferred name and icon
+//	POST /token      issues a MagicToken, which logs in to the account once, eg from the command line client
+//
+// Logging in sets the session cookie, which is sent with later requests, including the websocket upgrade.
+type Handler struct {
+	store    *Store
+	sessions *tokens
+	magic    *tokens
+	// Secure marks the session cookie as HTTPS only
+	Secure bool
+}
+
+// Registration is the request to create an account
+type Registration struct {
+	Username string
+	Password string
+	Name     string
+	Icon     string
+}
+
+// Login is the request to log in. It has either a username and password, or a magic token.
+type Login struct {
+	Username string `json:",omitempty"`
+	Password string `json:",omitempty"`
+	Token    string `json:",omitempty"`
+}
+
+// MagicToken logs in to an account once, before it expires
+type MagicToken struct {
+	Token   string
+	Expires time.Time
+}
+
+// NewHandler creates the account HTTP API. Sessions last for sessionDuration, and magic tokens can be used
+// within tokenDuration.
+func NewHandler(store *Store, sessionDuration, tokenDuration time.Duration) *Handler {
+	return &Handler{
+		store:    store,
+		sessions: newTokens(sessionDuration),
+		magic:    newTokens(tokenDuration),
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+
+	if path == "me" {
+		switch r.Method {
+		case http.MethodGet:
+			h.getProfile(w, r)
+		case http.MethodPut:
+			h.updateProfile(w, r)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	actions := map[string]func(http.ResponseWriter, *http.Request){
+		"register": h.register,
+		"login":    h.login,
+		"logout":   h.logout,
+		"token":    h.issueToken,
+	}
+	action, ok := actions[path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	action(w, r)
+}
+
+// Account returns the account the request is logged in to. It returns ErrNotLoggedIn if there is no session
+// cookie, and ErrSessionExpired if the session is no longer valid.
+func (h *Handler) Account(r *http.Request) (Account, error) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return Account{}, ErrNotLoggedIn
+	}
+	username, ok := h.sessions.lookup(cookie.Value)
+	if !ok {
+		return Account{}, ErrSessionExpired
+	}
+	account, err := h.store.Get(username)
+	if err == ErrNotFound {
+		return Account{}, ErrSessionExpired
+	}
+	return account, err
+}
+
+func (h *Handler) register(w http.ResponseWriter, r *http.Request) {
+	var registration Registration
+	if !readJSON(w, r, &registration) {
+		return
+	}
+
+	account, err := h.store.Register(registration.Username, registration.Password, registration.Name,
+		registration.Icon)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	log.Println("Registered account", account.Username)
+	h.startSession(w, account, http.StatusCreated)
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	var login Login
+	if !readJSON(w, r, &login) {
+		return
+	}
+
+	var account Account
+	var err error
+	if login.Token != "" {
+		username, ok := h.magic.redeem(login.Token)
+		if !ok {
+			writeError(w, ErrInvalidToken)
+			return
+		}
+		account, err = h.store.Get(username)
+		if err == ErrNotFound {
+			err = ErrInvalidToken
+		}
+	} else {
+		account, err = h.store.Authenticate(login.Username, login.Password)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.startSession(w, account, http.StatusOK)
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		h.sessions.revoke(cookie.Value)
+	}
+	h.clearCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) getProfile(w http.ResponseWriter, r *http.Request) {
+	account, ok := h.loggedIn(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, account.Profile())
+}
+
+func (h *Handler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	account, ok := h.loggedIn(w, r)
+	if !ok {
+		return
+	}
+	var profile Profile
+	if !readJSON(w, r, &profile) {
+		return
+	}
+
+	account, err := h.store.UpdateProfile(account.Username, profile.Name, profile.Icon)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, account.Profile())
+}
+
+func (h *Handler) issueToken(w http.ResponseWriter, r *http.Request) {
+	account, ok := h.loggedIn(w, r)
+	if !ok {
+		return
+	}
+
+	token, expires, err := h.magic.issue(account.Username)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, MagicToken{Token: token, Expires: expires})
+}
+
+// loggedIn returns the account the request is logged in to. If it isn't logged in, an error is written and
+// false returned. A session that is no longer valid has its cookie cleared, so the player can carry on as a
+// guest.
+func (h *Handler) loggedIn(w http.ResponseWriter, r *http.Request) (Account, bool) {
+	account, err := h.Account(r)
+	if err == ErrSessionExpired {
+		h.clearCookie(w)
+	}
+	if err != nil {
+		writeError(w, err)
+		return Account{}, false
+	}
+	return account, true
+}
+
+func (h *Handler) startSession(w http.ResponseWriter, account Account, statusCode int) {
+	session, expires, err := h.sessions.issue(account.Username)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    session,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   h.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	writeJSON(w, statusCode, account.Profile())
+}
+
+func (h *Handler) clearCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// readJSON decodes the request body. If it can't, an error is written and false returned.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		http.Error(w, "Unable to read the request: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrInvalidUsername, ErrInvalidPassword:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case ErrUsernameTaken:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case ErrWrongPassword, ErrInvalidToken, ErrNotLoggedIn, ErrSessionExpired:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case ErrNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		log.Println("Account error:", err)
+		http.Error(w, fmt.Sprint("Internal error: ", err), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		log.Println("Unable to write JSON response:", err)
+	}
+}
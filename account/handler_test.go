@@ -0,0 +1,138 @@
+package account
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) (*Handler, *httptest.Server) {
+	t.Helper()
+	handler := NewHandler(newTestStore(t), time.Hour, time.Minute)
+	return handler, httptest.NewServer(http.StripPrefix("/account", handler))
+}
+
+// newClient returns a client that keeps cookies, like a browser
+func newClient(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Jar: jar}
+}
+
+// call makes a request with a JSON body, decoding the JSON response into result if it succeeded
+func call(t *testing.T, client *http.Client, method string, url string, body string, result interface{}) int {
+	t.Helper()
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if result != nil && response.StatusCode < 300 {
+		if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return response.StatusCode
+}
+
+func TestHandler_RegisterLoginLogout(t *testing.T) {
+	handler, server := newTestServer(t)
+	defer server.Close()
+	browser := newClient(t)
+
+	var profile Profile
+	status := call(t, browser, http.MethodPost, server.URL+"/account/register",
+		`{"Username": "karl", "Password": "correct horse", "Name": "Karl", "Icon": "Horse3"}`, &profile)
+	if status != http.StatusCreated || profile != (Profile{Username: "karl", Name: "Karl", Icon: "Horse3"}) {
+		t.Errorf("Got %d %+v and expected karl's profile", status, profile)
+	}
+
+	// Registering logs in
+	status = call(t, browser, http.MethodPut, server.URL+"/account/me", `{"Icon": "Horse5"}`, &profile)
+	if status != http.StatusOK || profile.Name != "Karl" || profile.Icon != "Horse5" {
+		t.Errorf("Got %d %+v and expected the icon to change", status, profile)
+	}
+
+	// The session cookie identifies the player on other requests, like the websocket upgrade
+	request := httptest.NewRequest(http.MethodGet, server.URL+"/game", nil)
+	for _, cookie := range browser.Jar.Cookies(request.URL) {
+		request.AddCookie(cookie)
+	}
+	if account, err := handler.Account(request); err != nil || account.Username != "karl" {
+		t.Errorf("Got %+v %v and expected karl", account, err)
+	}
+
+	status = call(t, browser, http.MethodPost, server.URL+"/account/logout", "", nil)
+	if status != http.StatusNoContent {
+		t.Errorf("Got status %d and expected %d", status, http.StatusNoContent)
+	}
+	if status = call(t, browser, http.MethodGet, server.URL+"/account/me", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("Got status %d and expected to be logged out", status)
+	}
+
+	status = call(t, browser, http.MethodPost, server.URL+"/account/login",
+		`{"Username": "KARL", "Password": "wrong horse"}`, nil)
+	if status != http.StatusUnauthorized {
+		t.Errorf("Got status %d and expected the wrong password to be refused", status)
+	}
+	status = call(t, browser, http.MethodPost, server.URL+"/account/login",
+		`{"Username": "KARL", "Password": "correct horse"}`, &profile)
+	if status != http.StatusOK || profile.Username != "karl" {
+		t.Errorf("Got %d %+v and expected to log in to karl", status, profile)
+	}
+}
+
+func TestHandler_MagicToken(t *testing.T) {
+	_, server := newTestServer(t)
+	defer server.Close()
+	browser := newClient(t)
+	terminal := newClient(t)
+
+	call(t, browser, http.MethodPost, server.URL+"/account/register",
+		`{"Username": "karl", "Password": "correct horse"}`, nil)
+
+	var token MagicToken
+	if status := call(t, browser, http.MethodPost, server.URL+"/account/token", "", &token); status != http.StatusCreated {
+		t.Fatalf("Got status %d and expected %d", status, http.StatusCreated)
+	}
+
+	login := `{"Token": "` + token.Token + `"}`
+	var profile Profile
+	status := call(t, terminal, http.MethodPost, server.URL+"/account/login", login, &profile)
+	if status != http.StatusOK || profile.Username != "karl" {
+		t.Errorf("Got %d %+v and expected the token to log in to karl", status, profile)
+	}
+
+	// Tokens only work once
+	status = call(t, newClient(t), http.MethodPost, server.URL+"/account/login", login, nil)
+	if status != http.StatusUnauthorized {
+		t.Errorf("Got status %d and expected the used token to be refused", status)
+	}
+}
+
+func TestHandler_Account(t *testing.T) {
+	handler, server := newTestServer(t)
+	defer server.Close()
+
+	request := httptest.NewRequest(http.MethodGet, "/game", nil)
+	if _, err := handler.Account(request); err != ErrNotLoggedIn {
+		t.Errorf("Got %v and expected %v", err, ErrNotLoggedIn)
+	}
+
+	request.AddCookie(&http.Cookie{Name: SessionCookie, Value: "forgotten"})
+	if _, err := handler.Account(request); err != ErrSessionExpired {
+		t.Errorf("Got %v and expected %v", err, ErrSessionExpired)
+	}
+}
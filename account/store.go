@@ -0,0 +1,254 @@
+// Package account keeps player accounts, so a player has an identity that lasts between games and doesn't
+// depend on the name they play under
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MinPasswordLength is the shortest password an account can have
+const MinPasswordLength = 8
+
+// maxPasswordLength is the longest password bcrypt can hash
+const maxPasswordLength = 72
+
+var (
+	// ErrNotFound is returned when there is no account with the given username
+	ErrNotFound = errors.New("account not found")
+	// ErrUsernameTaken is returned when registering a username that already has an account
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrInvalidUsername is returned when the username isn't allowed
+	ErrInvalidUsername = errors.New("usernames must be 3-30 letters, digits, dashes or underscores")
+	// ErrInvalidPassword is returned when registering with a password that is too short or too long
+	ErrInvalidPassword = fmt.Errorf("passwords must be %d-%d characters", MinPasswordLength, maxPasswordLength)
+	// ErrWrongPassword is returned when logging in with the wrong username or password. It doesn't say which,
+	// so it can't be used to find out which usernames have accounts.
+	ErrWrongPassword = errors.New("wrong username or password")
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,30}$`)
+
+// Account is a registered player
+type Account struct {
+	// Username identifies the account. It is unique, ignoring case, and never changes.
+	Username string
+	// Name and Icon are what the player prefers to play as. They are used when the player doesn't pick others.
+	Name         string
+	Icon         string
+	PasswordHash []byte
+	Created      time.Time
+}
+
+// Profile is the part of an account that can be shown to its player
+type Profile struct {
+	Username string
+	Name     string
+	Icon     string
+}
+
+// Profile returns the account without its password hash
+func (a Account) Profile() Profile {
+	return Profile{
+		Username: a.Username,
+		Name:     a.Name,
+		Icon:     a.Icon,
+	}
+}
+
+// Store keeps the accounts. They are held in memory and saved to a JSON file whenever one changes.
+type Store struct {
+	file     string
+	lock     sync.RWMutex
+	accounts map[string]Account
+	// cost is the bcrypt cost of new password hashes
+	cost int
+	// dummyHash is checked against when logging in to an account that doesn't exist, so it takes as long as
+	// logging in with the wrong password
+	dummyHash []byte
+	dummyOnce sync.Once
+}
+
+// NewStore creates a store that saves the accounts to the given file, loading any accounts already in it
+func NewStore(file string) (*Store, error) {
+	s := &Store{
+		file:     file,
+		accounts: make(map[string]Account),
+		cost:     bcrypt.DefaultCost,
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []Account
+	err = json.Unmarshal(data, &accounts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", file, err)
+	}
+	for _, account := range accounts {
+		s.accounts[key(account.Username)] = account
+	}
+	return s, nil
+}
+
+// Register creates an account. The name defaults to the username.
+func (s *Store) Register(username, password, name, icon string) (Account, error) {
+	if !ValidUsername(username) {
+		return Account{}, ErrInvalidUsername
+	}
+	if len(password) < MinPasswordLength || len(password) > maxPasswordLength {
+		return Account{}, ErrInvalidPassword
+	}
+
+	// Hashing is slow on purpose, so it is done before taking the lock
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	if err != nil {
+		return Account{}, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.accounts[key(username)]; ok {
+		return Account{}, ErrUsernameTaken
+	}
+
+	if strings.TrimSpace(name) == "" {
+		name = username
+	}
+	account := Account{
+		Username:     username,
+		Name:         name,
+		Icon:         icon,
+		PasswordHash: hash,
+		Created:      time.Now().UTC(),
+	}
+	s.accounts[key(username)] = account
+
+	err = s.save()
+	if err != nil {
+		delete(s.accounts, key(username))
+		return Account{}, err
+	}
+	return account, nil
+}
+
+// Authenticate returns the account if the password is right
+func (s *Store) Authenticate(username, password string) (Account, error) {
+	account, err := s.Get(username)
+	if err == ErrNotFound {
+		bcrypt.CompareHashAndPassword(s.dummy(), []byte(password))
+		return Account{}, ErrWrongPassword
+	}
+	if err != nil {
+		return Account{}, err
+	}
+
+	err = bcrypt.CompareHashAndPassword(account.PasswordHash, []byte(password))
+	if err != nil {
+		return Account{}, ErrWrongPassword
+	}
+	return account, nil
+}
+
+// dummy returns a hash of a password no account has, at the cost of new password hashes
+func (s *Store) dummy() []byte {
+	s.dummyOnce.Do(func() {
+		s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not the password of any account"), s.cost)
+	})
+	return s.dummyHash
+}
+
+// Get returns the account with the given username, ignoring case
+func (s *Store) Get(username string) (Account, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	account, ok := s.accounts[key(username)]
+	if !ok {
+		return Account{}, ErrNotFound
+	}
+	return account, nil
+}
+
+// UpdateProfile changes the name and icon the player prefers. A blank name is left as it was.
+func (s *Store) UpdateProfile(username, name, icon string) (Account, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	account, ok := s.accounts[key(username)]
+	if !ok {
+		return Account{}, ErrNotFound
+	}
+	previous := account
+
+	if strings.TrimSpace(name) != "" {
+		account.Name = name
+	}
+	account.Icon = icon
+	s.accounts[key(username)] = account
+
+	err := s.save()
+	if err != nil {
+		s.accounts[key(username)] = previous
+		return Account{}, err
+	}
+	return account, nil
+}
+
+// save writes every account to the file. The lock must be held.
+func (s *Store) save() error {
+	accounts := make([]Account, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return key(accounts[i].Username) < key(accounts[j].Username)
+	})
+
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(s.file); dir != "" {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Write to a temporary file first, so a half-written file never replaces the accounts. Only the server
+	// should read it, since it holds the password hashes.
+	tempFile := s.file + ".tmp"
+	err = ioutil.WriteFile(tempFile, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempFile, s.file)
+}
+
+// ValidUsername returns true if the username can be used for an account
+func ValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+// key is how an account is looked up, so usernames that only differ by case are the same account
+func key(username string) string {
+	return strings.ToLower(username)
+}
@@ -0,0 +1,112 @@
+package account
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestStore creates an empty store that hashes passwords quickly
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStore(filepath.Join(t.TempDir(), "accounts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.cost = bcrypt.MinCost
+	return store
+}
+
+func TestStore_RegisterAndAuthenticate(t *testing.T) {
+	store := newTestStore(t)
+
+	_, err := store.Register("Karl", "correct horse", "", "Horse3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Usernames ignore case
+	account, err := store.Authenticate("karl", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Username != "Karl" || account.Name != "Karl" || account.Icon != "Horse3" {
+		t.Errorf("Got %+v and expected Karl with the name defaulting to the username", account)
+	}
+
+	if _, err := store.Authenticate("karl", "wrong horse"); err != ErrWrongPassword {
+		t.Errorf("Got %v and expected %v", err, ErrWrongPassword)
+	}
+	if _, err := store.Authenticate("nobody", "correct horse"); err != ErrWrongPassword {
+		t.Errorf("Got %v and expected %v for an unknown username", err, ErrWrongPassword)
+	}
+	if _, err := store.Register("KARL", "another password", "", ""); err != ErrUsernameTaken {
+		t.Errorf("Got %v and expected %v", err, ErrUsernameTaken)
+	}
+}
+
+func TestStore_RegisterChecksDetails(t *testing.T) {
+	store := newTestStore(t)
+
+	tests := []struct {
+		username string
+		password string
+		expected error
+	}{
+		{"ka", "correct horse", ErrInvalidUsername},
+		{"karl santa", "correct horse", ErrInvalidUsername},
+		{"karl", "short", ErrInvalidPassword},
+	}
+	for _, test := range tests {
+		if _, err := store.Register(test.username, test.password, "", ""); err != test.expected {
+			t.Errorf("%q %q: Got %v and expected %v", test.username, test.password, err, test.expected)
+		}
+	}
+}
+
+func TestStore_SurvivesRestart(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "accounts.json")
+	store, err := NewStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.cost = bcrypt.MinCost
+	if _, err := store.Register("karl", "correct horse", "Karl", "Horse3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UpdateProfile("karl", "", "Horse5"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := reopened.Authenticate("karl", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Name != "Karl" || account.Icon != "Horse5" {
+		t.Errorf("Got %+v and expected the updated profile to be kept", account)
+	}
+}
+
+func TestTokens_Expire(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	tokens := newTokens(time.Minute)
+	tokens.now = func() time.Time { return now }
+
+	token, _, err := tokens.issue("karl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username, ok := tokens.lookup(token); !ok || username != "karl" {
+		t.Errorf("Got %s %v and expected the token to be for karl", username, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := tokens.lookup(token); ok {
+		t.Error("Expected the token to have expired")
+	}
+}
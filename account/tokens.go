@@ -0,0 +1,95 @@
+package account
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// tokenBytes is how much randomness is in a token, enough that they can't be guessed
+const tokenBytes = 32
+
+// tokens hands out random strings that stand for an account until they expire. They are only kept in memory,
+// so they are forgotten when the server restarts.
+type tokens struct {
+	lock     sync.Mutex
+	duration time.Duration
+	entries  map[string]tokenEntry
+	now      func() time.Time
+}
+
+type tokenEntry struct {
+	username string
+	expires  time.Time
+}
+
+func newTokens(duration time.Duration) *tokens {
+	return &tokens{
+		duration: duration,
+		entries:  make(map[string]tokenEntry),
+		now:      time.Now,
+	}
+}
+
+// issue creates a token for the account, returning it and when it expires
+func (t *tokens) issue(username string) (string, time.Time, error) {
+	random := make([]byte, tokenBytes)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(random)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Forget expired tokens now and then, so they don't pile up
+	now := t.now()
+	for old, entry := range t.entries {
+		if !now.Before(entry.expires) {
+			delete(t.entries, old)
+		}
+	}
+
+	expires := now.Add(t.duration)
+	t.entries[token] = tokenEntry{username: username, expires: expires}
+	return token, expires, nil
+}
+
+// lookup returns the username the token stands for, or false if the token is unknown or has expired
+func (t *tokens) lookup(token string) (string, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.find(token)
+}
+
+// redeem is like lookup, but the token can't be used again
+func (t *tokens) redeem(token string) (string, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	username, ok := t.find(token)
+	delete(t.entries, token)
+	return username, ok
+}
+
+// find looks up an unexpired token. The lock must be held.
+func (t *tokens) find(token string) (string, bool) {
+	entry, ok := t.entries[token]
+	if !ok {
+		return "", false
+	}
+	if !t.now().Before(entry.expires) {
+		delete(t.entries, token)
+		return "", false
+	}
+	return entry.username, true
+}
+
+// revoke forgets the token
+func (t *tokens) revoke(token string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.entries, token)
+}
@@ -0,0 +1,164 @@
+// Package api serves a read-only view of the games, for dashboards, chat bots and stream overlays that don't
+// speak the websocket protocol
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/game"
+	"github.com/ksanta/wordofthedaygame/lobby"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler serves the game API. It expects to be mounted with the prefix stripped, eg
+// http.StripPrefix("/api/games", handler), and serves:
+//
+//	GET /              lists the games and their state
+//	GET /{id}          returns the state of the game in the room called id
+//	GET /{id}/events   streams the messages broadcast to the game's players as server-sent events
+type Handler struct {
+	lobby *lobby.Lobby
+	// StreamDuration ends event streams after this long, so the server's write timeout doesn't cut them off
+	// part way through an event. EventSource clients reconnect by themselves. Zero means no limit.
+	StreamDuration time.Duration
+	closing        chan struct{}
+	closeOnce      sync.Once
+}
+
+// Game is the state of the game in a room
+type Game struct {
+	ID string
+	game.State
+}
+
+// NewHandler creates the game API for the games in the lobby's rooms
+func NewHandler(l *lobby.Lobby) *Handler {
+	return &Handler{
+		lobby:   l,
+		closing: make(chan struct{}),
+	}
+}
+
+// Close ends every event stream, eg when the server is shutting down
+func (h *Handler) Close() {
+	h.closeOnce.Do(func() {
+		close(h.closing)
+	})
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case parts[0] == "":
+		h.listGames(w)
+	case len(parts) == 1:
+		h.getGame(w, parts[0])
+	case len(parts) == 2 && parts[1] == "events":
+		h.streamEvents(w, r, parts[0])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) listGames(w http.ResponseWriter) {
+	rooms := h.lobby.Rooms()
+	games := make([]Game, 0, len(rooms))
+	for _, room := range rooms {
+		games = append(games, Game{ID: room.Name, State: room.Game.State()})
+	}
+	writeJSON(w, games)
+}
+
+func (h *Handler) getGame(w http.ResponseWriter, id string) {
+	room, ok := h.lobby.Get(id)
+	if !ok {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, Game{ID: room.Name, State: room.Game.State()})
+}
+
+// streamEvents sends the game's state as a "State" event, then each message broadcast to the players as an
+// event named after its kind. The data of each event is JSON, the same as the players are sent.
+func (h *Handler) streamEvents(w http.ResponseWriter, r *http.Request, id string) {
+	room, ok := h.lobby.Get(id)
+	if !ok {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// Watch before taking the state, so nothing is missed in between
+	messages, stop := room.Game.Watch()
+	defer stop()
+
+	var timeout <-chan time.Time
+	if h.StreamDuration > 0 {
+		timer := time.NewTimer(h.StreamDuration)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	// Reconnect quickly when the stream ends
+	fmt.Fprint(w, "retry: 1000\n")
+	if !writeEvent(w, "State", Game{ID: room.Name, State: room.Game.State()}) {
+		return
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				// The game has let the watcher go, because it fell behind or the game shut down
+				return
+			}
+			if !writeEvent(w, message.Kind(), message) {
+				return
+			}
+			flusher.Flush()
+		case <-timeout:
+			return
+		case <-h.closing:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes a server-sent event with JSON data. It returns false if the event couldn't be written.
+func writeEvent(w http.ResponseWriter, name string, v interface{}) bool {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Println("Unable to encode event:", err)
+		return false
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	return err == nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Println("Unable to write response:", err)
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/ksanta/wordofthedaygame/game"
+	"github.com/ksanta/wordofthedaygame/game/gametest"
+	"github.com/ksanta/wordofthedaygame/lobby"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startAPI runs a game in the room called "team", with the API in front of it
+func startAPI(t *testing.T) (*gametest.Harness, *httptest.Server) {
+	t.Helper()
+	words := gametest.Words(5, "noun")
+	h := gametest.NewHarness(t, game.NewGame(words.GroupByType(), 500, 3, 10*time.Second, 7))
+
+	l := lobby.NewLobby(func(string, lobby.Options) (*game.Game, error) { return h.Game, nil }, nil)
+	if _, err := l.Join("team", lobby.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.StripPrefix("/api/games", NewHandler(l)))
+	return h, server
+}
+
+func getGame(t *testing.T, url string) (Game, int) {
+	t.Helper()
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var g Game
+	if response.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(response.Body).Decode(&g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g, response.StatusCode
+}
+
+// readEvent reads the next server-sent event, returning its name and data
+func readEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestHandler_GetGame(t *testing.T) {
+	h, server := startAPI(t)
+	defer h.Close()
+	defer server.Close()
+
+	h.JoinAll("alice")
+
+	g, status := getGame(t, server.URL+"/api/games/team")
+	if status != http.StatusOK {
+		t.Fatalf("Got status %d and expected 200", status)
+	}
+	if g.ID != "team" || g.Phase != "Lobby" || len(g.Players) != 1 || g.Players[0].Name != "alice" {
+		t.Errorf("Got %+v and expected alice waiting in the team room", g)
+	}
+
+	_, status = getGame(t, server.URL+"/api/games/nowhere")
+	if status != http.StatusNotFound {
+		t.Errorf("Got status %d and expected 404", status)
+	}
+}
+
+func TestHandler_ListGames(t *testing.T) {
+	h, server := startAPI(t)
+	defer h.Close()
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/api/games")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var games []Game
+	if err := json.NewDecoder(response.Body).Decode(&games); err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 || games[0].ID != "team" {
+		t.Errorf("Got %+v and expected the team game", games)
+	}
+}
+
+func TestHandler_StreamEvents(t *testing.T) {
+	h, server := startAPI(t)
+	defer h.Close()
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/api/games/team/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	events := bufio.NewReader(response.Body)
+
+	if name, _ := readEvent(t, events); name != "State" {
+		t.Errorf("Got %s and expected the stream to start with the State", name)
+	}
+
+	alice := h.JoinAll("alice")[0]
+	if name, _ := readEvent(t, events); name != "RoundSummary" {
+		t.Errorf("Got %s and expected a RoundSummary when alice joined", name)
+	}
+
+	h.Start()
+	alice.Expect(gametest.IsAboutToStart)
+	if name, _ := readEvent(t, events); name != "AboutToStart" {
+		t.Errorf("Got %s and expected AboutToStart", name)
+	}
+
+	h.Clock.FireNext(t)
+	question := alice.Expect(gametest.IsPresentQuestion).PresentQuestion
+	name, data := readEvent(t, events)
+	if name != "PresentQuestion" || !strings.Contains(data, question.WordToGuess) {
+		t.Errorf("Got %s %s and expected the question", name, data)
+	}
+
+	// The answer is kept secret while the question is open
+	g, _ := getGame(t, server.URL+"/api/games/team")
+	if g.Phase != "Question" || g.Word != question.WordToGuess || g.Answer != "" {
+		t.Errorf("Got %+v and expected the question without its answer", g)
+	}
+
+	alice.Answer(gametest.CorrectOption(question))
+	alice.Expect(gametest.IsPlayerResult)
+	if name, _ := readEvent(t, events); name != "RoundSummary" {
+		t.Errorf("Got %s and expected a RoundSummary after the answer", name)
+	}
+
+	g, _ = getGame(t, server.URL+"/api/games/team")
+	correct := question.Definitions[gametest.CorrectOption(question)]
+	if g.Phase != "Reveal" || g.Answer != correct {
+		t.Errorf("Got %+v and expected the answer %q to be revealed", g, correct)
+	}
+}
@@ -3,11 +3,16 @@ package cache
 import "github.com/ksanta/wordofthedaygame/model"
 
 type Cache interface {
-	// SetupRequired returns true if the cache does not exist
+	// SetupRequired returns true if the cache does not exist, or if populating it was interrupted
 	SetupRequired() bool
 
+	// Resumable returns true if an earlier attempt to populate the cache was interrupted. The words that made it
+	// into the cache can still be loaded, and CreateCacheWriter will add to them.
+	Resumable() bool
+
 	// CreateCacheWriter creates a consumer that listens on the returned channel and persists all words sent to the
-	// channel to the cache
+	// channel to the cache. Each word is persisted as it arrives so an interrupted run can be resumed. The cache is
+	// marked as complete once the channel is closed.
 	CreateCacheWriter() chan model.Word
 
 	// LoadWordsFromCache loads all the words from the cache
@@ -8,6 +8,9 @@ import (
 	"os"
 )
 
+// checkpointSuffix names the marker file that exists while the cache is being populated
+const checkpointSuffix = ".partial"
+
 type FileCache struct {
 	cacheFile string
 }
@@ -19,14 +22,27 @@ func NewFileCache(cacheFile string) Cache {
 
 func (cache *FileCache) SetupRequired() bool {
 	_, err := os.Stat(cache.cacheFile)
-	return os.IsNotExist(err)
+	return os.IsNotExist(err) || cache.Resumable()
+}
+
+func (cache *FileCache) Resumable() bool {
+	_, err := os.Stat(cache.checkpointFile())
+	return err == nil
 }
 
 func (cache *FileCache) CreateCacheWriter() chan model.Word {
 	wordChannel := make(chan model.Word)
 
 	go func() {
-		file, err := os.Create(cache.cacheFile)
+		// Marking the cache as partial before it is touched means a crash at any point can be resumed
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if cache.Resumable() {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		} else if err := cache.createCheckpoint(); err != nil {
+			log.Fatal(err)
+		}
+
+		file, err := os.OpenFile(cache.cacheFile, flags, 0644)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -38,8 +54,17 @@ func (cache *FileCache) CreateCacheWriter() chan model.Word {
 			if err != nil {
 				log.Fatal(err)
 			}
+			// Flush every word, so nothing is lost if the process dies
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		err = os.Remove(cache.checkpointFile())
+		if err != nil {
+			log.Println("Unable to mark the cache as complete:", err)
 		}
-		csvWriter.Flush()
 	}()
 
 	return wordChannel
@@ -48,10 +73,18 @@ func (cache *FileCache) CreateCacheWriter() chan model.Word {
 func (cache *FileCache) LoadWordsFromCache() model.Words {
 	var words model.Words
 	file, err := os.Open(cache.cacheFile)
+	if os.IsNotExist(err) && cache.Resumable() {
+		// Interrupted before the first word was written
+		return words
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer file.Close()
+
 	wordReader := csv.NewReader(file)
+	// Caches written before the Source column was added have fewer fields per record
+	wordReader.FieldsPerRecord = -1
 	for {
 		record, err := wordReader.Read()
 		if err == io.EOF {
@@ -65,3 +98,15 @@ func (cache *FileCache) LoadWordsFromCache() model.Words {
 	}
 	return words
 }
+
+func (cache *FileCache) checkpointFile() string {
+	return cache.cacheFile + checkpointSuffix
+}
+
+func (cache *FileCache) createCheckpoint() error {
+	file, err := os.Create(cache.checkpointFile())
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
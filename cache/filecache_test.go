@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var firstWord = model.Word{Word: "one", WordType: "noun", Definition: "the first", URL: "http://one", Source: "test"}
+var secondWord = model.Word{Word: "two", WordType: "verb", Definition: "the second", URL: "http://two", Source: "test"}
+
+// waitForComplete waits for the cache writer to mark the cache as complete
+func waitForComplete(t *testing.T, c Cache) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Resumable() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the cache to be marked as complete")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFileCache_Populate(t *testing.T) {
+	c := NewFileCache(filepath.Join(t.TempDir(), "words.cache"))
+	if !c.SetupRequired() {
+		t.Error("Expected setup to be required for a new cache")
+	}
+	if c.Resumable() {
+		t.Error("Expected a new cache not to be resumable")
+	}
+
+	writer := c.CreateCacheWriter()
+	writer <- firstWord
+	writer <- secondWord
+	close(writer)
+	waitForComplete(t, c)
+
+	if c.SetupRequired() {
+		t.Error("Expected setup not to be required once the cache is complete")
+	}
+	words := c.LoadWordsFromCache()
+	if len(words) != 2 || words[0] != firstWord || words[1] != secondWord {
+		t.Errorf("Got %v and expected %v", words, model.Words{firstWord, secondWord})
+	}
+}
+
+func TestFileCache_Resume(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "words.cache")
+
+	// Simulate a run that was interrupted after the first word was written
+	err := ioutil.WriteFile(cacheFile, []byte("one,noun,the first,http://one,test\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(cacheFile+checkpointSuffix, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewFileCache(cacheFile)
+	if !c.SetupRequired() || !c.Resumable() {
+		t.Fatal("Expected an interrupted cache to need setup and be resumable")
+	}
+
+	words := c.LoadWordsFromCache()
+	if len(words) != 1 || words[0] != firstWord {
+		t.Fatalf("Got %v and expected %v", words, model.Words{firstWord})
+	}
+
+	// Resuming adds to the words that are already there
+	writer := c.CreateCacheWriter()
+	writer <- secondWord
+	close(writer)
+	waitForComplete(t, c)
+
+	words = c.LoadWordsFromCache()
+	if len(words) != 2 || words[0] != firstWord || words[1] != secondWord {
+		t.Errorf("Got %v and expected %v", words, model.Words{firstWord, secondWord})
+	}
+}
+
+func TestFileCache_LoadOldFormat(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "words.cache")
+	err := ioutil.WriteFile(cacheFile, []byte("one,noun,the first,http://one\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words := NewFileCache(cacheFile).LoadWordsFromCache()
+	expected := model.Word{Word: "one", WordType: "noun", Definition: "the first", URL: "http://one"}
+	if len(words) != 1 || words[0] != expected {
+		t.Errorf("Got %v and expected %v", words, model.Words{expected})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ksanta/wordofthedaygame/account"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// session is the cookie from logging in with -token. It is sent when connecting to the game, so the player
+// plays as their account.
+var session *http.Cookie
+
+// profile is the logged in account, whose name is offered when asking for the player's details
+var profile *account.Profile
+
+// logIn trades a magic token for a session
+func logIn(token string) {
+	body, err := json.Marshal(account.Login{Token: token})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	u := serverURL("/account/login", false)
+	response, err := httpClient.Post(u.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Fatal("login error:", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(response.Body)
+		log.Fatalf("Login failed (%s): %s", response.Status, strings.TrimSpace(string(message)))
+	}
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == account.SessionCookie {
+			session = cookie
+		}
+	}
+
+	profile = &account.Profile{}
+	err = json.NewDecoder(response.Body).Decode(profile)
+	if err != nil {
+		log.Fatal("login error:", err)
+	}
+	log.Println("Logged in as", profile.Username)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage:")
+	fmt.Fprintln(out, "  client [flags]                           play the game")
+	fmt.Fprintln(out, "  client [flags] upload-deck <name> <file> upload a CSV, JSON or YAML word list as a deck")
+	fmt.Fprintln(out, "  client [flags] loadtest [loadtest flags]  play many games at once and report latencies")
+	fmt.Fprintln(out, "Flags:")
+	flag.PrintDefaults()
+}
+
+// uploadDeck sends a word list to the server, which saves it as a named deck
+func uploadDeck(args []string) {
+	if len(args) != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name, fileName := args[0], args[1]
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	u := serverURL("/decks/"+name, false)
+	format := strings.TrimPrefix(filepath.Ext(fileName), ".")
+	u.RawQuery = url.Values{"format": {format}}.Encode()
+
+	request, err := http.NewRequest(http.MethodPut, u.String(), file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		log.Fatal("upload error:", err)
+	}
+	defer response.Body.Close()
+
+	body, _ := ioutil.ReadAll(response.Body)
+	if response.StatusCode != http.StatusCreated {
+		log.Fatalf("Upload failed (%s): %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	fmt.Println("Uploaded deck", name+":", strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/ksanta/wordofthedaygame/cache"
+	"github.com/ksanta/wordofthedaygame/model"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// loadTestSettings controls how many virtual players are started and how they play
+type loadTestSettings struct {
+	players    int
+	rooms      int
+	accuracy   float64
+	minLatency time.Duration
+	maxLatency time.Duration
+	rampUp     time.Duration
+	timeout    time.Duration
+	// definitions maps each word to its definition, so virtual players can pick the right answer
+	definitions map[string]string
+}
+
+// loadTestStats is shared by all the virtual players in a load test
+type loadTestStats struct {
+	sync.Mutex
+	joinLatencies   []time.Duration
+	answerLatencies []time.Duration
+	dialErrors      int
+	rejected        int
+	gameErrors      int
+	lateAnswers     int
+	disconnects     int
+	gamesFinished   int
+	correctAnswers  int
+}
+
+// botCapabilities are the kinds of message a virtual player handles. Leaving out round summaries saves the
+// server sending one to every player each time anyone answers.
+var botCapabilities = []string{
+	model.KindWelcome,
+	model.KindHost,
+	model.KindPresentQuestion,
+	model.KindPlayerResult,
+	model.KindSummary,
+	model.KindError,
+}
+
+// roomHosts keeps the host token for each room, which virtual players are given when they become host
+type roomHosts struct {
+	sync.Mutex
+	tokens map[string]string
+}
+
+func (h *roomHosts) set(room string, token string) {
+	h.Lock()
+	defer h.Unlock()
+	h.tokens[room] = token
+}
+
+func (h *roomHosts) get(room string) string {
+	h.Lock()
+	defer h.Unlock()
+	return h.tokens[room]
+}
+
+// loadTest plays many games at once against a server and reports how it coped
+func loadTest(args []string) {
+	flags := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	players := flags.Int("players", 100, "Number of virtual players")
+	rooms := flags.Int("rooms", 20, "Number of rooms to spread the players across")
+	accuracy := flags.Float64("accuracy", 0.7, "Fraction of questions answered correctly, when -words is given")
+	minLatency := flags.Duration("minLatency", 500*time.Millisecond, "Shortest time a virtual player takes to answer")
+	maxLatency := flags.Duration("maxLatency", 3*time.Second, "Longest time a virtual player takes to answer")
+	rampUp := flags.Duration("rampUp", 5*time.Second, "Time taken to connect all the players")
+	timeout := flags.Duration("timeout", 5*time.Minute, "Give up on games that haven't finished after this long")
+	wordsFile := flags.String("words", "", "Word cache the server is using. Without it, answers are random guesses.")
+	flags.Parse(args)
+
+	if *players < 1 || *rooms < 1 || *minLatency > *maxLatency {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	settings := loadTestSettings{
+		players:     *players,
+		rooms:       *rooms,
+		accuracy:    *accuracy,
+		minLatency:  *minLatency,
+		maxLatency:  *maxLatency,
+		rampUp:      *rampUp,
+		timeout:     *timeout,
+		definitions: make(map[string]string),
+	}
+	if *wordsFile != "" {
+		for _, word := range cache.NewFileCache(*wordsFile).LoadWordsFromCache() {
+			settings.definitions[strings.ToLower(word.Word)] = word.Definition
+		}
+	}
+
+	fmt.Printf("Starting %d players in %d rooms against %s using %s\n", settings.players, settings.rooms, *addr, *encoding)
+	start := time.Now()
+	stats := runLoadTest(settings)
+	stats.report(os.Stdout, time.Since(start))
+}
+
+// runLoadTest connects the players, starts each room once its players have joined and waits for every game to end
+func runLoadTest(settings loadTestSettings) *loadTestStats {
+	stats := &loadTestStats{}
+	hosts := &roomHosts{tokens: make(map[string]string)}
+	deadline := time.Now().Add(settings.timeout)
+
+	roomJoins := make([]sync.WaitGroup, settings.rooms)
+	for i := 0; i < settings.players; i++ {
+		roomJoins[i%settings.rooms].Add(1)
+	}
+
+	var finished sync.WaitGroup
+	for i := 0; i < settings.rooms; i++ {
+		go func(room int) {
+			roomJoins[room].Wait()
+			startRoom(roomName(room), hosts.get(roomName(room)))
+		}(i)
+	}
+
+	for i := 0; i < settings.players; i++ {
+		room := i % settings.rooms
+		bot := &virtualPlayer{
+			name:     fmt.Sprintf("bot%d", i+1),
+			room:     roomName(room),
+			settings: settings,
+			stats:    stats,
+			hosts:    hosts,
+			joined:   roomJoins[room].Done,
+		}
+		finished.Add(1)
+		go func() {
+			defer finished.Done()
+			bot.play(deadline)
+		}()
+		time.Sleep(settings.rampUp / time.Duration(settings.players))
+	}
+
+	finished.Wait()
+	return stats
+}
+
+func roomName(room int) string {
+	return fmt.Sprintf("loadtest-%d", room+1)
+}
+
+// startRoom asks the server to start the game in a room, as the room's host
+func startRoom(room string, hostToken string) {
+	u := serverURL("/start", false)
+	u.RawQuery = url.Values{"room": {room}}.Encode()
+	request, err := http.NewRequest(http.MethodPost, u.String(), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+hostToken)
+	response, err := httpClient.Do(request)
+	if err != nil {
+		log.Println("Unable to start room", room+":", err)
+		return
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		log.Println("Unable to start room", room+":", response.Status)
+	}
+}
+
+// virtualPlayer plays one game, answering each question after a random delay
+type virtualPlayer struct {
+	name     string
+	room     string
+	settings loadTestSettings
+	stats    *loadTestStats
+	hosts    *roomHosts
+	joined   func()
+
+	joinOnce     sync.Once
+	writeMutex   sync.Mutex
+	conn         *websocket.Conn
+	answerSentAt time.Time
+	answerMutex  sync.Mutex
+}
+
+func (bot *virtualPlayer) play(deadline time.Time) {
+	// The room is started once every player has joined or given up trying
+	defer bot.joinOnce.Do(bot.joined)
+
+	dialStart := time.Now()
+	u := serverURL("/game", true)
+	u.RawQuery = url.Values{"room": {bot.room}}.Encode()
+	conn, err := dial(u)
+	if err != nil {
+		bot.stats.record(func(s *loadTestStats) { s.dialErrors++ })
+		return
+	}
+	bot.conn = conn
+	defer conn.Close()
+
+	// A game that runs past the deadline ends with a read error, which is counted as a disconnect
+	conn.SetReadDeadline(deadline)
+
+	// The player's details are sent straight after the hello, without waiting for the server's hello back
+	bot.send(model.MessageFromPlayer{
+		Hello: &model.Hello{Version: model.ProtocolVersion, Capabilities: botCapabilities},
+	})
+	bot.send(model.MessageFromPlayer{PlayerDetailsResp: &model.PlayerDetails{Name: bot.name}})
+
+	hasJoined := false
+	for {
+		msg, err := receiveMessage(conn)
+		if err != nil {
+			bot.stats.record(func(s *loadTestStats) { s.disconnects++ })
+			return
+		}
+
+		if msg.Welcome != nil {
+			joinLatency := time.Since(dialStart)
+			bot.stats.record(func(s *loadTestStats) { s.joinLatencies = append(s.joinLatencies, joinLatency) })
+			hasJoined = true
+			if msg.Welcome.HostToken != "" {
+				bot.hosts.set(bot.room, msg.Welcome.HostToken)
+			}
+			bot.joinOnce.Do(bot.joined)
+
+		} else if msg.Host != nil {
+			bot.hosts.set(bot.room, msg.Host.Token)
+
+		} else if msg.PresentQuestion != nil {
+			bot.answerLater(msg.PresentQuestion)
+
+		} else if msg.PlayerResult != nil {
+			bot.handleResult(msg.PlayerResult)
+
+		} else if msg.Error != nil {
+			// An error before the welcome means the room turned the player away
+			if !hasJoined {
+				bot.stats.record(func(s *loadTestStats) { s.rejected++ })
+				return
+			}
+			if msg.Error.Code == model.ErrorQuestionClosed {
+				// The answer was sent after the question timed out, so it won't get a result
+				bot.answerMutex.Lock()
+				bot.answerSentAt = time.Time{}
+				bot.answerMutex.Unlock()
+				bot.stats.record(func(s *loadTestStats) { s.lateAnswers++ })
+				continue
+			}
+			bot.stats.record(func(s *loadTestStats) { s.gameErrors++ })
+
+		} else if msg.Summary != nil {
+			bot.stats.record(func(s *loadTestStats) { s.gamesFinished++ })
+			bot.writeMutex.Lock()
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			bot.writeMutex.Unlock()
+			return
+		}
+	}
+}
+
+// answerLater picks an answer and sends it after a random delay, without holding up the read loop
+func (bot *virtualPlayer) answerLater(q *model.PresentQuestion) {
+	response := bot.pickAnswer(q)
+	spread := int64(bot.settings.maxLatency - bot.settings.minLatency)
+	delay := bot.settings.minLatency
+	if spread > 0 {
+		delay += time.Duration(rand.Int63n(spread))
+	}
+
+	time.AfterFunc(delay, func() {
+		bot.answerMutex.Lock()
+		bot.answerSentAt = time.Now()
+		bot.answerMutex.Unlock()
+		bot.send(model.MessageFromPlayer{
+			PlayerResponse: &model.PlayerResponse{QuestionID: q.QuestionID, Response: response},
+		})
+	})
+}
+
+// pickAnswer answers correctly as often as the accuracy setting asks, if it knows the correct answer
+func (bot *virtualPlayer) pickAnswer(q *model.PresentQuestion) int {
+	correct := -1
+	if definition, ok := bot.settings.definitions[strings.ToLower(q.WordToGuess)]; ok {
+		for i, option := range q.Definitions {
+			if option == definition {
+				correct = i
+			}
+		}
+	}
+
+	if correct == -1 {
+		return rand.Intn(len(q.Definitions))
+	}
+	if rand.Float64() < bot.settings.accuracy || len(q.Definitions) == 1 {
+		return correct
+	}
+	// Any option other than the correct one
+	wrong := rand.Intn(len(q.Definitions) - 1)
+	if wrong >= correct {
+		wrong++
+	}
+	return wrong
+}
+
+func (bot *virtualPlayer) handleResult(result *model.PlayerResult) {
+	bot.answerMutex.Lock()
+	sentAt := bot.answerSentAt
+	bot.answerSentAt = time.Time{}
+	bot.answerMutex.Unlock()
+
+	bot.stats.record(func(s *loadTestStats) {
+		// A result without an answer means the question timed out
+		if !sentAt.IsZero() {
+			s.answerLatencies = append(s.answerLatencies, time.Since(sentAt))
+		}
+		if result.Correct {
+			s.correctAnswers++
+		}
+	})
+}
+
+// send writes a message to the server. Answers are sent from timers, so writes have to take turns.
+func (bot *virtualPlayer) send(message model.MessageFromPlayer) {
+	bot.writeMutex.Lock()
+	defer bot.writeMutex.Unlock()
+	// A failed write shows up as a read error, where it is counted
+	sendMessage(bot.conn, message)
+}
+
+func (stats *loadTestStats) record(update func(*loadTestStats)) {
+	stats.Lock()
+	defer stats.Unlock()
+	update(stats)
+}
+
+func (stats *loadTestStats) report(out *os.File, elapsed time.Duration) {
+	stats.Lock()
+	defer stats.Unlock()
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Finished in %s\n", elapsed.Round(time.Millisecond))
+	fmt.Fprintf(out, "%-16s %d\n", "Games finished:", stats.gamesFinished)
+	fmt.Fprintf(out, "%-16s %d\n", "Joined:", len(stats.joinLatencies))
+	fmt.Fprintf(out, "%-16s %d\n", "Answers:", len(stats.answerLatencies))
+	fmt.Fprintf(out, "%-16s %d\n", "Correct:", stats.correctAnswers)
+	fmt.Fprintf(out, "%-16s %d\n", "Dial errors:", stats.dialErrors)
+	fmt.Fprintf(out, "%-16s %d\n", "Rejected:", stats.rejected)
+	fmt.Fprintf(out, "%-16s %d\n", "Late answers:", stats.lateAnswers)
+	fmt.Fprintf(out, "%-16s %d\n", "Game errors:", stats.gameErrors)
+	fmt.Fprintf(out, "%-16s %d\n", "Disconnects:", stats.disconnects)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "%-8s %10s %10s %10s %10s\n", "Latency", "p50", "p90", "p99", "max")
+	printPercentiles(out, "join", stats.joinLatencies)
+	printPercentiles(out, "answer", stats.answerLatencies)
+}
+
+func printPercentiles(out *os.File, name string, latencies []time.Duration) {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	format := func(p float64) string {
+		latency, ok := percentile(sorted, p)
+		if !ok {
+			return "-"
+		}
+		return latency.Round(time.Microsecond).String()
+	}
+	fmt.Fprintf(out, "%-8s %10s %10s %10s %10s\n", name, format(0.5), format(0.9), format(0.99), format(1))
+}
+
+// percentile returns the latency that the fraction p of the sorted latencies are at or below, using the
+// nearest-rank method. It returns false if there are no latencies.
+func percentile(sorted []time.Duration, p float64) (time.Duration, bool) {
+	if len(sorted) == 0 {
+		return 0, false
+	}
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index], true
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	ten := make([]time.Duration, 10)
+	for i := range ten {
+		ten[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		sorted   []time.Duration
+		p        float64
+		expected time.Duration
+		ok       bool
+	}{
+		{nil, 0.5, 0, false},
+		{[]time.Duration{time.Second}, 0.5, time.Second, true},
+		{[]time.Duration{time.Second}, 0, time.Second, true},
+		{ten, 0, time.Millisecond, true},
+		{ten, 0.5, 5 * time.Millisecond, true},
+		{ten, 0.9, 9 * time.Millisecond, true},
+		{ten, 0.95, 10 * time.Millisecond, true},
+		{ten, 0.99, 10 * time.Millisecond, true},
+		{ten, 1, 10 * time.Millisecond, true},
+	}
+	for _, test := range tests {
+		latency, ok := percentile(test.sorted, test.p)
+		if latency != test.expected || ok != test.ok {
+			t.Errorf("p%v of %v: Got %v, %v and expected %v, %v", test.p*100, test.sorted, latency, ok,
+				test.expected, test.ok)
+		}
+	}
+}
+
+func TestVirtualPlayer_PickAnswer(t *testing.T) {
+	question := &model.PresentQuestion{
+		WordToGuess: "Gallop",
+		Definitions: []string{"to walk", "to run fast", "to stand still"},
+	}
+	definitions := map[string]string{"gallop": "to run fast"}
+
+	tests := []struct {
+		accuracy    float64
+		definitions map[string]string
+		minCorrect  int
+		maxCorrect  int
+	}{
+		// Bots always or never answer correctly at the extremes
+		{1, definitions, 1000, 1000},
+		{0, definitions, 0, 0},
+		// Bots answer correctly about as often as the accuracy asks
+		{0.7, definitions, 600, 800},
+		// Bots that don't know the word guess, so are right about a third of the time
+		{1, nil, 200, 470},
+	}
+	for _, test := range tests {
+		bot := &virtualPlayer{settings: loadTestSettings{accuracy: test.accuracy, definitions: test.definitions}}
+		correct := 0
+		for i := 0; i < 1000; i++ {
+			answer := bot.pickAnswer(question)
+			if answer < 0 || answer >= len(question.Definitions) {
+				t.Fatalf("Got answer %d and expected one of the %d options", answer, len(question.Definitions))
+			}
+			if answer == 1 {
+				correct++
+			}
+		}
+		if correct < test.minCorrect || correct > test.maxCorrect {
+			t.Errorf("Accuracy %v: Got %d correct and expected %d-%d", test.accuracy, correct, test.minCorrect,
+				test.maxCorrect)
+		}
+	}
+}
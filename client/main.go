@@ -2,28 +2,72 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
+	"github.com/ksanta/wordofthedaygame/codec"
 	"github.com/ksanta/wordofthedaygame/model"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-var addr = flag.String("addr", "localhost:8080", "http service address")
+var (
+	addr  = flag.String("addr", "localhost:8080", "Server address, or a URL such as wss://example.com:8443 to connect with TLS")
+	room  = flag.String("room", "", "Room to join. Leave blank for the default room.")
+	decks = flag.String("decks", "", "Comma separated decks to use if this creates a new room")
+	// encoding picks the codec, which is asked for with the websocket subprotocol
+	encoding = flag.String("encoding", "json", "Message encoding: json or msgpack")
+	token    = flag.String("token", "", "Magic token from /account/token, to play as your account")
+	caCert   = flag.String("caCert", "", "PEM file of CA certificates to trust, eg the server's self-signed certificate")
+)
 
 var timeoutChan = make(chan struct{})
 
+// capabilities are the kinds of message the read loop handles. The server doesn't send any other kinds.
+var capabilities = []string{
+	model.KindPlayerDetailsReq,
+	model.KindWelcome,
+	model.KindAboutToStart,
+	model.KindPresentQuestion,
+	model.KindPlayerResult,
+	model.KindRoundSummary,
+	model.KindSummary,
+	model.KindError,
+}
+
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 	log.SetFlags(0)
 
+	if _, ok := codec.ForName(*encoding); !ok {
+		log.Println("Unknown encoding", *encoding)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	setUpTLS()
+
+	if flag.Arg(0) == "upload-deck" {
+		uploadDeck(flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "loadtest" {
+		loadTest(flag.Args()[1:])
+		return
+	}
+
+	if *token != "" {
+		logIn(*token)
+	}
+
 	conn := connectToServer()
 	defer conn.Close()
 
@@ -35,13 +79,28 @@ func main() {
 }
 
 func connectToServer() *websocket.Conn {
-	u := url.URL{Scheme: "ws", Host: *addr, Path: "/game"}
+	query := url.Values{}
+	if *room != "" {
+		query.Set("room", *room)
+	}
+	if *decks != "" {
+		query.Set("decks", *decks)
+	}
+	u := serverURL("/game", true)
+	u.RawQuery = query.Encode()
 	log.Printf("connecting to %s", u.String())
 
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	conn, err := dial(u)
 	if err != nil {
 		log.Fatal("dial error:", err)
 	}
+
+	err = sendMessage(conn, model.MessageFromPlayer{
+		Hello: &model.Hello{Version: model.ProtocolVersion, Capabilities: capabilities},
+	})
+	if err != nil {
+		log.Fatal("Send Hello err", err)
+	}
 	return conn
 }
 
@@ -54,7 +113,7 @@ func startReadLoop(conn *websocket.Conn) chan struct{} {
 			close(done)
 		}()
 		for {
-			msg, err := receiveJSON(conn)
+			msg, err := receiveMessage(conn)
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
 					log.Println(err)
@@ -62,13 +121,18 @@ func startReadLoop(conn *websocket.Conn) chan struct{} {
 				return
 			}
 
-			// Delegate to handlers depending on message contents
+			// Delegate to handlers depending on message contents. Anything else is a newer kind of message
+			// that this client doesn't know about, and is ignored.
 			if msg.PlayerDetailsReq != nil {
+				// The server asks for the player's details after its hello
 				handlePlayerDetailsReqMessage(conn)
 
 			} else if msg.Welcome != nil {
 				handleIntroMessage(msg.Welcome)
 
+			} else if msg.AboutToStart != nil {
+				handleAboutToStart(msg.AboutToStart)
+
 			} else if msg.PresentQuestion != nil {
 				// Run in separate goroutine, so we can listen for timeout msg too
 				go handlePresentQuestionMessage(conn, msg.PresentQuestion)
@@ -83,8 +147,10 @@ func startReadLoop(conn *websocket.Conn) chan struct{} {
 				handleSummary(msg.Summary)
 				return
 
-			} else {
-				log.Fatal("Unsupported message", msg)
+			} else if msg.Error != nil {
+				if !handleError(conn, msg.Error) {
+					return
+				}
 			}
 		}
 	}()
@@ -92,6 +158,12 @@ func startReadLoop(conn *websocket.Conn) chan struct{} {
 }
 
 func handlePlayersResult(result *model.PlayerResult) {
+	// If the player is still being asked for an answer, the question has timed out
+	select {
+	case timeoutChan <- struct{}{}:
+	default:
+	}
+
 	fmt.Println()
 	if result.Correct {
 		fmt.Print("✅ ")
@@ -108,6 +180,42 @@ func handleRoundSummary(summary *model.RoundSummary) {
 	}
 }
 
+func handleAboutToStart(aboutToStart *model.AboutToStart) {
+	fmt.Println()
+	fmt.Println("The game starts in", aboutToStart.Seconds, "seconds!")
+}
+
+// handleError explains the error to the player, asking for their details again if they couldn't be used. It
+// returns false if the player can't carry on playing.
+func handleError(conn *websocket.Conn, gameError *model.GameError) bool {
+	fmt.Println()
+	switch gameError.Code {
+	case model.ErrorGameInProgress:
+		fmt.Println("⚠️ A game is already being played in this room.")
+		if gameError.RetryAfter > 0 {
+			fmt.Printf("It should finish in about %d seconds. ", gameError.RetryAfter)
+		}
+		fmt.Println("Try again then, or pick another room with -room.")
+		return false
+	case model.ErrorShuttingDown:
+		// The server closes the connection once it has sent the final summary
+		fmt.Println("⚠️ The server is shutting down. Try again once it is back up.")
+	case model.ErrorNoWords:
+		fmt.Println("⚠️ The room has run out of words. Try a room with other decks, using -room and -decks.")
+	case model.ErrorUnsupportedVersion:
+		fmt.Println("⚠️ This client is too old for the server. Update it and try again.")
+		return false
+	case model.ErrorQuestionClosed:
+		fmt.Println("⏰ Your answer arrived after the question closed.")
+	case model.ErrorInvalidName, model.ErrorInvalidIcon:
+		fmt.Println("⚠️", gameError.Message)
+		handlePlayerDetailsReqMessage(conn)
+	default:
+		fmt.Println("⚠️", gameError.Message)
+	}
+	return true
+}
+
 func handleSummary(summary *model.Summary) {
 	fmt.Println()
 	fmt.Println("You scored", summary.TotalPoints, "points!")
@@ -122,11 +230,24 @@ func handlePresentQuestionMessage(conn *websocket.Conn, q *model.PresentQuestion
 	}
 	fmt.Print("\nEnter your best guess: ")
 
-	response := getAnswerFromPlayer()
+	answer, ok := getAnswerFromPlayer()
+	if !ok {
+		// The question has closed, so the server would reject an answer
+		return
+	}
+
+	// Options are shown to the player starting from 1, but the server counts from 0. Anything that isn't a
+	// number becomes -1 which is never correct.
+	response, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil {
+		response = 0
+	}
+	response--
 
-	err := conn.WriteJSON(model.MessageFromPlayer{
+	err = sendMessage(conn, model.MessageFromPlayer{
 		PlayerResponse: &model.PlayerResponse{
-			Response: response,
+			QuestionID: q.QuestionID,
+			Response:   response,
 		},
 	})
 	if err != nil {
@@ -135,12 +256,17 @@ func handlePresentQuestionMessage(conn *websocket.Conn, q *model.PresentQuestion
 }
 
 func handlePlayerDetailsReqMessage(conn *websocket.Conn) {
-	fmt.Print("Enter your name: ")
+	// Logged in players can leave the name blank to play as their account's
+	if profile != nil {
+		fmt.Printf("Enter your name [%s]: ", profile.Name)
+	} else {
+		fmt.Print("Enter your name: ")
+	}
 	scanner := bufio.NewScanner(os.Stdin)
 	scanner.Scan()
 	playerDetailsResp := model.PlayerDetails{Name: scanner.Text()}
 
-	err := conn.WriteJSON(model.MessageFromPlayer{
+	err := sendMessage(conn, model.MessageFromPlayer{
 		PlayerDetailsResp: &playerDetailsResp,
 	})
 	if err != nil {
@@ -149,6 +275,9 @@ func handlePlayerDetailsReqMessage(conn *websocket.Conn) {
 }
 
 func handleIntroMessage(intro *model.Welcome) {
+	if intro.Name != "" {
+		fmt.Println("Welcome,", intro.Name+"!")
+	}
 	fmt.Println("Playing for", intro.TargetScore, "points.")
 	fmt.Println("Waiting for other players.")
 }
@@ -182,7 +311,8 @@ func startWriteLoop(conn *websocket.Conn, done chan struct{}) {
 	}
 }
 
-func getAnswerFromPlayer() string {
+// getAnswerFromPlayer returns the player's answer, or false if the question timed out first
+func getAnswerFromPlayer() (string, bool) {
 	stdinChannel := make(chan string, 1)
 
 	// Get the answer from the player in a different goroutine and send to the channel
@@ -194,27 +324,50 @@ func getAnswerFromPlayer() string {
 
 	select {
 	case response := <-stdinChannel:
-		return response
+		return response, true
 	case <-timeoutChan:
 		fmt.Println("💥 Too slow! 💥")
 		// On timeout, the goroutine is still blocked waiting for user input.
 		// Prompt the player to hit enter to finish the goroutine
 		fmt.Print("Hit enter to move to the next question")
 		<-stdinChannel
-		return ""
+		return "", false
+	}
+}
+
+// dial connects to the server, asking for the codec picked with -encoding. A server that doesn't know the
+// codec's subprotocol uses JSON.
+func dial(u url.URL) (*websocket.Conn, error) {
+	c, _ := codec.ForName(*encoding)
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = []string{c.Subprotocol()}
+	dialer.TLSClientConfig = tlsConfig
+	header := http.Header{}
+	if session != nil {
+		header.Set("Cookie", session.Name+"="+session.Value)
+	}
+	conn, _, err := dialer.Dial(u.String(), header)
+	return conn, err
+}
+
+// sendMessage encodes the message with the codec agreed when the connection was made
+func sendMessage(conn *websocket.Conn, message model.MessageFromPlayer) error {
+	c := codec.ForSubprotocol(conn.Subprotocol())
+	data, err := c.Marshal(message)
+	if err != nil {
+		return err
 	}
+	return conn.WriteMessage(c.FrameType(), data)
 }
 
-func receiveJSON(conn *websocket.Conn) (model.MessageToPlayer, error) {
-	_, jsonBytes, err := conn.ReadMessage()
+func receiveMessage(conn *websocket.Conn) (model.MessageToPlayer, error) {
+	_, data, err := conn.ReadMessage()
 	if err != nil {
 		return model.MessageToPlayer{}, err
 	}
-	// Uncomment for debugging
-	//log.Print("-> ", string(jsonBytes))
 
 	var response model.MessageToPlayer
-	err = json.Unmarshal(jsonBytes, &response)
+	err = codec.ForSubprotocol(conn.Subprotocol()).Unmarshal(data, &response)
 	if err != nil {
 		return model.MessageToPlayer{}, err
 	}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/tls"
+	"github.com/ksanta/wordofthedaygame/security"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// tlsConfig is used to connect to servers using TLS. It trusts the -caCert certificates as well as the system's.
+var tlsConfig = &tls.Config{}
+
+// httpClient makes every request to the server other than the websocket
+var httpClient = http.DefaultClient
+
+// setUpTLS trusts the -caCert certificates. Call it after flag.Parse.
+func setUpTLS() {
+	if *caCert == "" {
+		return
+	}
+	pool, err := security.CertPool(*caCert)
+	if err != nil {
+		log.Fatal("Unable to load -caCert: ", err)
+	}
+	tlsConfig.RootCAs = pool
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	httpClient = &http.Client{Transport: transport}
+}
+
+// serverURL returns the URL of a path on the -addr server, as a websocket URL or a plain HTTP one. -addr is a host
+// and port, or a URL such as wss://example.com:8443 to connect with TLS.
+func serverURL(path string, websocket bool) url.URL {
+	host := *addr
+	secure := false
+	if strings.Contains(*addr, "://") {
+		u, err := url.Parse(*addr)
+		if err != nil {
+			log.Fatal("Invalid -addr: ", err)
+		}
+		host = u.Host
+		switch u.Scheme {
+		case "wss", "https":
+			secure = true
+		case "ws", "http":
+		default:
+			log.Fatalf("Invalid -addr: %s isn't ws, wss, http or https", u.Scheme)
+		}
+	}
+
+	scheme := "http"
+	if websocket {
+		scheme = "ws"
+	}
+	if secure {
+		scheme += "s"
+	}
+	return url.URL{Scheme: scheme, Host: host, Path: path}
+}
@@ -0,0 +1,97 @@
+// Package codec encodes the model messages sent over the websocket. JSON is the default, and clients can ask
+// for a binary encoding instead by requesting its websocket subprotocol when they connect.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+// Codec turns messages into websocket frames and back again
+type Codec interface {
+	// Name is how the codec is picked by flags, eg "json"
+	Name() string
+	// Subprotocol is the websocket subprotocol a client requests to use the codec
+	Subprotocol() string
+	// FrameType is the websocket message type the codec writes, websocket.TextMessage or websocket.BinaryMessage
+	FrameType() int
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON encodes messages as JSON text. It is used when the client doesn't ask for a subprotocol.
+	JSON Codec = jsonCodec{}
+	// MessagePack encodes messages as binary MessagePack, which is smaller and quicker to decode
+	MessagePack Codec = msgpackCodec{}
+)
+
+// All lists every codec, in the order the server prefers them
+var All = []Codec{MessagePack, JSON}
+
+// Subprotocols returns the subprotocols of every codec, in the order the server prefers them
+func Subprotocols() []string {
+	subprotocols := make([]string, len(All))
+	for i, c := range All {
+		subprotocols[i] = c.Subprotocol()
+	}
+	return subprotocols
+}
+
+// ForSubprotocol returns the codec for the subprotocol agreed when the websocket connected. Connections without
+// a subprotocol use JSON.
+func ForSubprotocol(subprotocol string) Codec {
+	for _, c := range All {
+		if c.Subprotocol() == subprotocol {
+			return c
+		}
+	}
+	return JSON
+}
+
+// ForName returns the codec with the given name, or false if there isn't one
+func ForName(name string) (Codec, bool) {
+	for _, c := range All {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string        { return "json" }
+func (jsonCodec) Subprotocol() string { return "wordofthedaygame.json" }
+func (jsonCodec) FrameType() int      { return websocket.TextMessage }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// msgpackCodec uses the JSON field names and tags, so both codecs carry the same messages
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string        { return "msgpack" }
+func (msgpackCodec) Subprotocol() string { return "wordofthedaygame.msgpack" }
+func (msgpackCodec) FrameType() int      { return websocket.BinaryMessage }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer).UseJSONTag(true).UseCompactEncoding(true)
+	err := encoder.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.NewDecoder(bytes.NewReader(data)).UseJSONTag(true).Decode(v)
+}
@@ -0,0 +1,134 @@
+package codec
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"reflect"
+	"testing"
+)
+
+// messagesToPlayer has a message of every kind the server sends, with every field set
+var messagesToPlayer = []model.MessageToPlayer{
+	{Seq: 1, Hello: &model.Hello{Version: 2, Capabilities: []string{model.KindHello, model.KindPlayerResponse}}},
+	{Seq: 2, PlayerDetailsReq: &model.PlayerDetailsReq{Icons: []model.Icon{
+		{ID: "Horse1", URL: "/images/Horse1.png"},
+	}}},
+	{Seq: 3, Welcome: &model.Welcome{TargetScore: 500, Name: "alice 2", Icon: "Horse1", HostToken: "c2VjcmV0"}},
+	{Seq: 4, Host: &model.Host{Token: "c2VjcmV0"}},
+	{Seq: 5, AboutToStart: &model.AboutToStart{Seconds: 5}},
+	{Seq: 6, PresentQuestion: &model.PresentQuestion{
+		QuestionID:     7,
+		WordToGuess:    "flummox",
+		Definitions:    []string{"to confuse", "to fly", "a flummery"},
+		SecondsAllowed: 10,
+	}},
+	{Seq: 7, PlayerResult: &model.PlayerResult{QuestionID: 7, Correct: true, Points: 140, CorrectAnswer: 2}},
+	{Seq: 8, RoundSummary: &model.RoundSummary{PlayerStates: []model.PlayerState{
+		{Name: "alice", Icon: "Horse1", Score: 140, Active: true, Account: "alice"},
+		{Name: "bob", Icon: "Horse2", Score: 0, Active: false},
+	}}},
+	{Seq: 9, Summary: &model.Summary{Winner: "alice", Icon: "Horse1", TotalPoints: 560, Account: "alice"}},
+	{Seq: 10, Error: &model.GameError{Code: model.ErrorGameInProgress, Message: "Game is already in progress", RetryAfter: 48}},
+}
+
+// messagesFromPlayer has a message of every kind a client sends
+var messagesFromPlayer = []model.MessageFromPlayer{
+	{Hello: &model.Hello{Version: 2, Capabilities: []string{model.KindWelcome, model.KindSummary}}},
+	{PlayerDetailsResp: &model.PlayerDetails{Name: "alice", Icon: "Horse1"}},
+	{PlayerResponse: &model.PlayerResponse{QuestionID: 7, Response: 2}},
+	{Disconnected: &model.Disconnected{}},
+}
+
+// checkEveryKindIsCovered fails the test if a field of the message struct isn't set in any of the messages, so
+// new kinds of message can't be left out of the conformance tests
+func checkEveryKindIsCovered(t *testing.T, messages interface{}) {
+	t.Helper()
+	list := reflect.ValueOf(messages)
+	messageType := list.Type().Elem()
+	for i := 0; i < messageType.NumField(); i++ {
+		covered := false
+		for j := 0; j < list.Len(); j++ {
+			if !list.Index(j).Field(i).IsZero() {
+				covered = true
+			}
+		}
+		if !covered {
+			t.Errorf("%s.%s isn't covered", messageType.Name(), messageType.Field(i).Name)
+		}
+	}
+}
+
+func TestCodec_RoundTripMessagesToPlayer(t *testing.T) {
+	checkEveryKindIsCovered(t, messagesToPlayer)
+
+	for _, c := range All {
+		for _, message := range messagesToPlayer {
+			data, err := c.Marshal(message)
+			if err != nil {
+				t.Fatalf("%s: %v", c.Name(), err)
+			}
+			var got model.MessageToPlayer
+			err = c.Unmarshal(data, &got)
+			if err != nil {
+				t.Fatalf("%s: %v", c.Name(), err)
+			}
+			if !reflect.DeepEqual(got, message) {
+				t.Errorf("%s: Got %+v and expected %+v", c.Name(), got, message)
+			}
+		}
+	}
+}
+
+func TestCodec_RoundTripMessagesFromPlayer(t *testing.T) {
+	checkEveryKindIsCovered(t, messagesFromPlayer)
+
+	for _, c := range All {
+		for _, message := range messagesFromPlayer {
+			data, err := c.Marshal(message)
+			if err != nil {
+				t.Fatalf("%s: %v", c.Name(), err)
+			}
+			var got model.MessageFromPlayer
+			err = c.Unmarshal(data, &got)
+			if err != nil {
+				t.Fatalf("%s: %v", c.Name(), err)
+			}
+			if !reflect.DeepEqual(got, message) {
+				t.Errorf("%s: Got %+v and expected %+v", c.Name(), got, message)
+			}
+		}
+	}
+}
+
+func TestCodec_UnknownKindsAreIgnored(t *testing.T) {
+	newerMessage := map[string]interface{}{
+		"Emote":          map[string]interface{}{"Emoji": "🎉"},
+		"PlayerResponse": map[string]interface{}{"QuestionID": 3, "Response": 1},
+	}
+
+	for _, c := range All {
+		data, err := c.Marshal(newerMessage)
+		if err != nil {
+			t.Fatalf("%s: %v", c.Name(), err)
+		}
+		var got model.MessageFromPlayer
+		err = c.Unmarshal(data, &got)
+		if err != nil {
+			t.Errorf("%s: Got %v and expected the unknown kind to be ignored", c.Name(), err)
+			continue
+		}
+		if got.PlayerResponse == nil || got.PlayerResponse.QuestionID != 3 || got.PlayerResponse.Response != 1 {
+			t.Errorf("%s: Got %+v and expected the PlayerResponse", c.Name(), got)
+		}
+	}
+}
+
+func TestCodec_ForSubprotocol(t *testing.T) {
+	if ForSubprotocol("") != JSON {
+		t.Error("Expected connections without a subprotocol to use JSON")
+	}
+	for _, c := range All {
+		if got := ForSubprotocol(c.Subprotocol()); got != c {
+			t.Errorf("Got %s and expected %s", got.Name(), c.Name())
+		}
+	}
+}
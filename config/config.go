@@ -0,0 +1,407 @@
+// Package config holds the server settings. Settings start with their defaults, and can be changed by a YAML,
+// JSON or TOML file, then by environment variables.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/deck"
+	"github.com/ksanta/wordofthedaygame/names"
+	"github.com/ksanta/wordofthedaygame/player"
+	"github.com/ksanta/wordofthedaygame/scraper"
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config holds every server setting
+type Config struct {
+	HTTP       HTTP       `yaml:"http" json:"http" toml:"http"`
+	Cache      Cache      `yaml:"cache" json:"cache" toml:"cache"`
+	Scraper    Scraper    `yaml:"scraper" json:"scraper" toml:"scraper"`
+	Decks      Decks      `yaml:"decks" json:"decks" toml:"decks"`
+	Rules      Rules      `yaml:"rules" json:"rules" toml:"rules"`
+	Connection Connection `yaml:"connection" json:"connection" toml:"connection"`
+	Accounts   Accounts   `yaml:"accounts" json:"accounts" toml:"accounts"`
+	Names      Names      `yaml:"names" json:"names" toml:"names"`
+	Icons      Icons      `yaml:"icons" json:"icons" toml:"icons"`
+	Security   Security   `yaml:"security" json:"security" toml:"security"`
+	// Rooms changes the rules for particular rooms, by room name. Only the rules given are changed.
+	Rooms map[string]Rules `yaml:"rooms" json:"rooms" toml:"rooms"`
+}
+
+// HTTP configures the web server
+type HTTP struct {
+	Addr              string   `yaml:"addr" json:"addr" toml:"addr"`
+	ReadHeaderTimeout Duration `yaml:"readHeaderTimeout" json:"readHeaderTimeout" toml:"readHeaderTimeout"`
+	ReadTimeout       Duration `yaml:"readTimeout" json:"readTimeout" toml:"readTimeout"`
+	WriteTimeout      Duration `yaml:"writeTimeout" json:"writeTimeout" toml:"writeTimeout"`
+	IdleTimeout       Duration `yaml:"idleTimeout" json:"idleTimeout" toml:"idleTimeout"`
+	// ShutdownGrace is how long games in progress have to finish when the server shuts down
+	ShutdownGrace Duration `yaml:"shutdownGrace" json:"shutdownGrace" toml:"shutdownGrace"`
+	// TLSCertFile and TLSKeyFile are a PEM encoded certificate and private key. The server serves HTTPS when
+	// they are given.
+	TLSCertFile string `yaml:"tlsCertFile" json:"tlsCertFile" toml:"tlsCertFile"`
+	TLSKeyFile  string `yaml:"tlsKeyFile" json:"tlsKeyFile" toml:"tlsKeyFile"`
+	// TLSSelfSigned serves HTTPS with a certificate made when the server starts. It is saved to TLSCertFile, if
+	// given, for clients to trust.
+	TLSSelfSigned bool `yaml:"tlsSelfSigned" json:"tlsSelfSigned" toml:"tlsSelfSigned"`
+	// StaticDir serves the web client from a directory instead of the copy built into the server, so changes to
+	// it show up without rebuilding
+	StaticDir string `yaml:"staticDir" json:"staticDir" toml:"staticDir"`
+}
+
+// TLS returns true if the server serves HTTPS
+func (h HTTP) TLS() bool {
+	return h.TLSSelfSigned || h.TLSCertFile != ""
+}
+
+// Cache configures where the words of the day are kept
+type Cache struct {
+	Type string `yaml:"type" json:"type" toml:"type"`
+	File string `yaml:"file" json:"file" toml:"file"`
+	// Limit is the max number of words to cache from each source
+	Limit int `yaml:"limit" json:"limit" toml:"limit"`
+}
+
+// Scraper configures where words come from and how politely they are scraped
+type Scraper struct {
+	Sources        []string `yaml:"sources" json:"sources" toml:"sources"`
+	WiktionaryDump string   `yaml:"wiktionaryDump" json:"wiktionaryDump" toml:"wiktionaryDump"`
+	WordFile       string   `yaml:"wordFile" json:"wordFile" toml:"wordFile"`
+	Concurrency    int      `yaml:"concurrency" json:"concurrency" toml:"concurrency"`
+	// RequestsPerSecond caps the overall request rate. Zero means no limit.
+	RequestsPerSecond float64  `yaml:"requestsPerSecond" json:"requestsPerSecond" toml:"requestsPerSecond"`
+	MaxRetries        int      `yaml:"maxRetries" json:"maxRetries" toml:"maxRetries"`
+	RetryBackoff      Duration `yaml:"retryBackoff" json:"retryBackoff" toml:"retryBackoff"`
+	UserAgent         string   `yaml:"userAgent" json:"userAgent" toml:"userAgent"`
+	IgnoreRobotsTxt   bool     `yaml:"ignoreRobotsTxt" json:"ignoreRobotsTxt" toml:"ignoreRobotsTxt"`
+	// MinWordsPerType is how many words a type needs before players can join
+	MinWordsPerType int `yaml:"minWordsPerType" json:"minWordsPerType" toml:"minWordsPerType"`
+}
+
+// Decks configures the word decks
+type Decks struct {
+	// Dir is where uploaded decks are saved
+	Dir string `yaml:"dir" json:"dir" toml:"dir"`
+	// Default are the decks used by rooms that don't pick their own
+	Default []string `yaml:"default" json:"default" toml:"default"`
+}
+
+// Rules are the game rules. When used to override the rules for a room, zero values are left unchanged.
+type Rules struct {
+	TargetScore        int      `yaml:"targetScore" json:"targetScore" toml:"targetScore"`
+	OptionsPerQuestion int      `yaml:"optionsPerQuestion" json:"optionsPerQuestion" toml:"optionsPerQuestion"`
+	QuestionDuration   Duration `yaml:"questionDuration" json:"questionDuration" toml:"questionDuration"`
+	MaxPlayers         int      `yaml:"maxPlayers" json:"maxPlayers" toml:"maxPlayers"`
+	CountdownDuration  Duration `yaml:"countdownDuration" json:"countdownDuration" toml:"countdownDuration"`
+	RevealDuration     Duration `yaml:"revealDuration" json:"revealDuration" toml:"revealDuration"`
+	// CorrectPoints are earned for a correct answer
+	CorrectPoints int `yaml:"correctPoints" json:"correctPoints" toml:"correctPoints"`
+	// SpeedPoints are earned for answering straight away, falling to zero as the time runs out
+	SpeedPoints int `yaml:"speedPoints" json:"speedPoints" toml:"speedPoints"`
+	// MixedTypes lets the options in a question have different word types. It is a pointer so a room can turn
+	// it off when the game rules turn it on.
+	MixedTypes *bool `yaml:"mixedTypes" json:"mixedTypes" toml:"mixedTypes"`
+}
+
+// MixesTypes returns whether the options in a question can have different word types
+func (r Rules) MixesTypes() bool {
+	return r.MixedTypes != nil && *r.MixedTypes
+}
+
+// Connection configures the player websocket connections
+type Connection struct {
+	PongWait       Duration `yaml:"pongWait" json:"pongWait" toml:"pongWait"`
+	WriteWait      Duration `yaml:"writeWait" json:"writeWait" toml:"writeWait"`
+	MaxMessageSize int64    `yaml:"maxMessageSize" json:"maxMessageSize" toml:"maxMessageSize"`
+	SendQueueSize  int      `yaml:"sendQueueSize" json:"sendQueueSize" toml:"sendQueueSize"`
+	// OverflowPolicy is "drop-stale" or "disconnect"
+	OverflowPolicy string `yaml:"overflowPolicy" json:"overflowPolicy" toml:"overflowPolicy"`
+}
+
+// Accounts configures player accounts
+type Accounts struct {
+	// File is where the accounts are saved
+	File string `yaml:"file" json:"file" toml:"file"`
+	// SessionDuration is how long a player stays logged in
+	SessionDuration Duration `yaml:"sessionDuration" json:"sessionDuration" toml:"sessionDuration"`
+	// TokenDuration is how long a magic login token can be used for
+	TokenDuration Duration `yaml:"tokenDuration" json:"tokenDuration" toml:"tokenDuration"`
+}
+
+// Names configures the names players can pick
+type Names struct {
+	// MaxLength is the longest name, in characters
+	MaxLength int `yaml:"maxLength" json:"maxLength" toml:"maxLength"`
+	// BlockedWordsFile lists the words that can't be used in names, one per line. The built-in list is used
+	// if it isn't given.
+	BlockedWordsFile string `yaml:"blockedWordsFile" json:"blockedWordsFile" toml:"blockedWordsFile"`
+}
+
+// Icons configures the avatars players upload
+type Icons struct {
+	// AvatarDir is where uploaded avatars are saved
+	AvatarDir string `yaml:"avatarDir" json:"avatarDir" toml:"avatarDir"`
+	// MaxUploadBytes is the biggest image that can be uploaded as an avatar
+	MaxUploadBytes int64 `yaml:"maxUploadBytes" json:"maxUploadBytes" toml:"maxUploadBytes"`
+	// MaxAvatars is how many avatars can be saved in AvatarDir
+	MaxAvatars int `yaml:"maxAvatars" json:"maxAvatars" toml:"maxAvatars"`
+}
+
+// Security configures who can connect to the game and start it
+type Security struct {
+	// AllowedOrigins are the websites, besides the server itself, whose pages can connect to the game and start
+	// it, eg "https://example.com". "*" allows every website.
+	AllowedOrigins []string `yaml:"allowedOrigins" json:"allowedOrigins" toml:"allowedOrigins"`
+	// AdminToken starts the game in any room. Without it, only each room's host can start its game.
+	AdminToken string `yaml:"adminToken" json:"adminToken" toml:"adminToken"`
+	// RequestsPerMinute is how many times a minute each IP address can connect to a game or start one, after
+	// the first RequestBurst
+	RequestsPerMinute int `yaml:"requestsPerMinute" json:"requestsPerMinute" toml:"requestsPerMinute"`
+	RequestBurst      int `yaml:"requestBurst" json:"requestBurst" toml:"requestBurst"`
+}
+
+// Default returns the settings used when nothing else is given
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Addr:              ":8080",
+			ReadHeaderTimeout: Duration{10 * time.Second},
+			ReadTimeout:       Duration{30 * time.Second},
+			WriteTimeout:      Duration{30 * time.Second},
+			IdleTimeout:       Duration{2 * time.Minute},
+			ShutdownGrace:     Duration{time.Minute},
+		},
+		Cache: Cache{
+			Type:  "file",
+			File:  "words.cache",
+			Limit: 3000,
+		},
+		Scraper: Scraper{
+			Sources:           []string{scraper.MeriamSource},
+			Concurrency:       4,
+			RequestsPerSecond: 10,
+			MaxRetries:        5,
+			RetryBackoff:      Duration{time.Second},
+			UserAgent:         scraper.DefaultUserAgent,
+			MinWordsPerType:   10,
+		},
+		Decks: Decks{
+			Dir:     "decks",
+			Default: []string{deck.WordOfTheDay},
+		},
+		Rules: Rules{
+			TargetScore:        500,
+			OptionsPerQuestion: 3,
+			QuestionDuration:   Duration{10 * time.Second},
+			MaxPlayers:         7,
+			CountdownDuration:  Duration{5 * time.Second},
+			RevealDuration:     Duration{2 * time.Second},
+			CorrectPoints:      100,
+			SpeedPoints:        50,
+		},
+		Connection: Connection{
+			PongWait:       Duration{60 * time.Second},
+			WriteWait:      Duration{10 * time.Second},
+			MaxMessageSize: 4096,
+			SendQueueSize:  32,
+			OverflowPolicy: "drop-stale",
+		},
+		Accounts: Accounts{
+			File:            "accounts.json",
+			SessionDuration: Duration{30 * 24 * time.Hour},
+			TokenDuration:   Duration{15 * time.Minute},
+		},
+		Names: Names{
+			MaxLength: names.DefaultMaxLength,
+		},
+		Icons: Icons{
+			AvatarDir:      "avatars",
+			MaxUploadBytes: 1 << 20,
+			MaxAvatars:     1000,
+		},
+		Security: Security{
+			RequestsPerMinute: 60,
+			RequestBurst:      20,
+		},
+	}
+}
+
+// LoadFile reads the settings in a YAML, JSON or TOML file over the top of config. The format is picked by the
+// file extension. Settings missing from the file are left as they are, and unknown settings are an error.
+func LoadFile(config *Config, fileName string) error {
+	contents, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(contents, config)
+	case ".json":
+		decoder := json.NewDecoder(strings.NewReader(string(contents)))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(config)
+	case ".toml":
+		decoder := toml.NewDecoder(strings.NewReader(string(contents)))
+		decoder.Strict(true)
+		err = decoder.Decode(config)
+	default:
+		return fmt.Errorf("unsupported config format %q", filepath.Ext(fileName))
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", fileName, err)
+	}
+	return nil
+}
+
+// RulesFor returns the rules for a room, which are the game rules with any overrides for the room applied
+func (c Config) RulesFor(room string) Rules {
+	rules := c.Rules
+	override, ok := c.Rooms[room]
+	if !ok {
+		return rules
+	}
+
+	if override.TargetScore != 0 {
+		rules.TargetScore = override.TargetScore
+	}
+	if override.OptionsPerQuestion != 0 {
+		rules.OptionsPerQuestion = override.OptionsPerQuestion
+	}
+	if override.QuestionDuration.Duration != 0 {
+		rules.QuestionDuration = override.QuestionDuration
+	}
+	if override.MaxPlayers != 0 {
+		rules.MaxPlayers = override.MaxPlayers
+	}
+	if override.CountdownDuration.Duration != 0 {
+		rules.CountdownDuration = override.CountdownDuration
+	}
+	if override.RevealDuration.Duration != 0 {
+		rules.RevealDuration = override.RevealDuration
+	}
+	if override.CorrectPoints != 0 {
+		rules.CorrectPoints = override.CorrectPoints
+	}
+	if override.SpeedPoints != 0 {
+		rules.SpeedPoints = override.SpeedPoints
+	}
+	if override.MixedTypes != nil {
+		rules.MixedTypes = override.MixedTypes
+	}
+	return rules
+}
+
+// Validate checks the settings make sense, and returns an error listing everything wrong with them
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, problem string) {
+		if !ok {
+			problems = append(problems, problem)
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr is required")
+	check(c.HTTP.ShutdownGrace.Duration >= 0, "http.shutdownGrace can't be negative")
+	if c.HTTP.TLSSelfSigned {
+		check(c.HTTP.TLSKeyFile == "", "http.tlsKeyFile can't be given with http.tlsSelfSigned")
+	} else {
+		check((c.HTTP.TLSCertFile == "") == (c.HTTP.TLSKeyFile == ""),
+			"http.tlsCertFile and http.tlsKeyFile must be given together")
+	}
+
+	check(c.Cache.Type == "file", "cache.type must be 'file'")
+	check(c.Cache.File != "", "cache.file is required")
+	check(c.Cache.Limit > 0, "cache.limit must be more than 0")
+
+	check(len(c.Scraper.Sources) > 0, "scraper.sources needs at least one source")
+	check(c.Scraper.Concurrency > 0, "scraper.concurrency must be more than 0")
+	check(c.Scraper.RequestsPerSecond >= 0, "scraper.requestsPerSecond can't be negative")
+	check(c.Scraper.MaxRetries >= 0, "scraper.maxRetries can't be negative")
+	check(c.Scraper.MinWordsPerType >= 0, "scraper.minWordsPerType can't be negative")
+
+	check(c.Decks.Dir != "", "decks.dir is required")
+	check(len(c.Decks.Default) > 0, "decks.default needs at least one deck")
+
+	problems = append(problems, c.Rules.problems("rules")...)
+	for room, override := range c.Rooms {
+		problems = append(problems, c.RulesFor(room).problems("rooms."+room)...)
+		check(override.QuestionDuration.Duration >= 0 && override.CountdownDuration.Duration >= 0 &&
+			override.RevealDuration.Duration >= 0, "rooms."+room+" durations can't be negative")
+	}
+
+	check(c.Connection.PongWait.Duration > 0, "connection.pongWait must be more than 0")
+	check(c.Connection.WriteWait.Duration > 0, "connection.writeWait must be more than 0")
+	check(c.Connection.MaxMessageSize > 0, "connection.maxMessageSize must be more than 0")
+	check(c.Connection.SendQueueSize > 0, "connection.sendQueueSize must be more than 0")
+	_, err := player.ParseOverflowPolicy(c.Connection.OverflowPolicy)
+	check(err == nil, "connection.overflowPolicy must be 'drop-stale' or 'disconnect'")
+
+	check(c.Accounts.File != "", "accounts.file is required")
+	check(c.Accounts.SessionDuration.Duration > 0, "accounts.sessionDuration must be more than 0")
+	check(c.Accounts.TokenDuration.Duration > 0, "accounts.tokenDuration must be more than 0")
+
+	check(c.Names.MaxLength > 0, "names.maxLength must be more than 0")
+
+	check(c.Icons.AvatarDir != "", "icons.avatarDir is required")
+	check(c.Icons.MaxUploadBytes > 0, "icons.maxUploadBytes must be more than 0")
+	check(c.Icons.MaxAvatars > 0, "icons.maxAvatars must be more than 0")
+
+	for _, origin := range c.Security.AllowedOrigins {
+		u, err := url.Parse(origin)
+		check(origin == "*" || (err == nil && u.Scheme != "" && u.Host != "" && strings.Trim(u.Path, "/") == ""),
+			fmt.Sprintf("security.allowedOrigins entry %q must be a scheme and host, eg https://example.com", origin))
+	}
+	check(c.Security.RequestsPerMinute > 0, "security.requestsPerMinute must be more than 0")
+	check(c.Security.RequestBurst > 0, "security.requestBurst must be more than 0")
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// problems returns what is wrong with the rules, naming each setting under the given prefix
+func (r Rules) problems(prefix string) []string {
+	var problems []string
+	check := func(ok bool, problem string) {
+		if !ok {
+			problems = append(problems, prefix+"."+problem)
+		}
+	}
+
+	check(r.TargetScore > 0, "targetScore must be more than 0")
+	check(r.OptionsPerQuestion >= 2, "optionsPerQuestion must be at least 2")
+	check(r.QuestionDuration.Duration > 0, "questionDuration must be more than 0")
+	check(r.MaxPlayers > 0, "maxPlayers must be more than 0")
+	check(r.CountdownDuration.Duration >= 0, "countdownDuration can't be negative")
+	check(r.RevealDuration.Duration >= 0, "revealDuration can't be negative")
+	check(r.CorrectPoints >= 0, "correctPoints can't be negative")
+	check(r.SpeedPoints >= 0, "speedPoints can't be negative")
+	check(r.CorrectPoints+r.SpeedPoints > 0, "correctPoints or speedPoints must be more than 0")
+	return problems
+}
+
+// Duration is a time.Duration written like "10s" or "1m30s" in config files and environment variables
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
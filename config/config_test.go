@@ -0,0 +1,162 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_LoadFile(t *testing.T) {
+	for _, fileName := range []string{"config.yaml", "config.json", "config.toml"} {
+		config := Default()
+		err := LoadFile(&config, filepath.Join("testdata", fileName))
+		if err != nil {
+			t.Fatalf("%s: %v", fileName, err)
+		}
+
+		if config.HTTP.Addr != ":9090" || config.HTTP.ShutdownGrace.Duration != 90*time.Second {
+			t.Errorf("%s: got %+v", fileName, config.HTTP)
+		}
+		if len(config.Scraper.Sources) != 2 || config.Scraper.Sources[1] != "wordnik" {
+			t.Errorf("%s: got sources %v and expected merriam-webster and wordnik", fileName, config.Scraper.Sources)
+		}
+		if config.Rules.TargetScore != 300 || config.Rules.QuestionDuration.Duration != 15*time.Second {
+			t.Errorf("%s: got rules %+v", fileName, config.Rules)
+		}
+		// Settings missing from the file keep their defaults
+		if config.Rules.MaxPlayers != 7 || config.Cache.File != "words.cache" {
+			t.Errorf("%s: expected the defaults to be kept, got %+v", fileName, config)
+		}
+		if config.Rooms["speedy"].SpeedPoints != 100 {
+			t.Errorf("%s: got room overrides %+v", fileName, config.Rooms)
+		}
+	}
+}
+
+func TestConfig_LoadFile_UnknownSetting(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "typo.yaml")
+	err := ioutil.WriteFile(fileName, []byte("rules:\n  targetScroe: 300\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := Default()
+	err = LoadFile(&config, fileName)
+	if err == nil || !strings.Contains(err.Error(), "targetScroe") {
+		t.Errorf("Got %v and expected an error naming the unknown setting", err)
+	}
+}
+
+func TestConfig_RulesFor(t *testing.T) {
+	config := Default()
+	err := LoadFile(&config, filepath.Join("testdata", "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	speedy := config.RulesFor("speedy")
+	if speedy.QuestionDuration.Duration != 5*time.Second || speedy.SpeedPoints != 100 {
+		t.Errorf("Got %+v and expected the room's overrides", speedy)
+	}
+	if speedy.TargetScore != 300 || speedy.CorrectPoints != 100 {
+		t.Errorf("Got %+v and expected the other rules to be kept", speedy)
+	}
+
+	if other := config.RulesFor("other"); other != config.Rules {
+		t.Errorf("Got %+v and expected the game rules", other)
+	}
+}
+
+func TestConfig_RulesFor_MixedTypes(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "mixed.yaml")
+	err := ioutil.WriteFile(fileName, []byte(
+		"rules:\n  mixedTypes: true\nrooms:\n  strict:\n    mixedTypes: false\n  speedy:\n    speedPoints: 100\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := Default()
+	if config.Rules.MixesTypes() {
+		t.Error("Got mixed types and expected them to be off by default")
+	}
+	if err := LoadFile(&config, fileName); err != nil {
+		t.Fatal(err)
+	}
+
+	// A room can turn mixed types off as well as on, and rooms that don't say keep the game rule
+	if config.RulesFor("strict").MixesTypes() {
+		t.Error("Got mixed types in the strict room and expected its override to turn them off")
+	}
+	if !config.RulesFor("speedy").MixesTypes() || !config.RulesFor("other").MixesTypes() {
+		t.Error("Got no mixed types and expected the game rule to be kept")
+	}
+}
+
+func TestConfig_ApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"WOTD_RULES_TARGET_SCORE":        "250",
+		"WOTD_RULES_QUESTION_DURATION":   "20s",
+		"WOTD_SCRAPER_SOURCES":           "wordnik, file",
+		"WOTD_SCRAPER_IGNORE_ROBOTS_TXT": "true",
+		"WOTD_HTTP_ADDR":                 ":7070",
+		"WOTD_RULES_MIXED_TYPES":         "true",
+	}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	config := Default()
+	err := ApplyEnv(&config, lookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Rules.TargetScore != 250 || config.Rules.QuestionDuration.Duration != 20*time.Second ||
+		!config.Rules.MixesTypes() {
+		t.Errorf("Got rules %+v", config.Rules)
+	}
+	if len(config.Scraper.Sources) != 2 || config.Scraper.Sources[1] != "file" || !config.Scraper.IgnoreRobotsTxt {
+		t.Errorf("Got scraper settings %+v", config.Scraper)
+	}
+	if config.HTTP.Addr != ":7070" {
+		t.Errorf("Got addr %s and expected :7070", config.HTTP.Addr)
+	}
+}
+
+func TestConfig_ApplyEnv_BadValue(t *testing.T) {
+	lookupEnv := func(name string) (string, bool) {
+		return "soon", name == "WOTD_HTTP_SHUTDOWN_GRACE"
+	}
+
+	config := Default()
+	err := ApplyEnv(&config, lookupEnv)
+	if err == nil || !strings.Contains(err.Error(), "WOTD_HTTP_SHUTDOWN_GRACE") {
+		t.Errorf("Got %v and expected an error naming the variable", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Expected the defaults to be valid, got %v", err)
+	}
+
+	config := Default()
+	config.Rules.OptionsPerQuestion = 1
+	config.Connection.OverflowPolicy = "explode"
+	config.Rooms = map[string]Rules{"broken": {CorrectPoints: -5}}
+	config.Security.AllowedOrigins = []string{"example.com"}
+	config.HTTP.TLSCertFile = "cert.pem"
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected an invalid config")
+	}
+	for _, setting := range []string{"rules.optionsPerQuestion", "connection.overflowPolicy", "rooms.broken.correctPoints",
+		"security.allowedOrigins", "http.tlsKeyFile"} {
+		if !strings.Contains(err.Error(), setting) {
+			t.Errorf("Got %v and expected it to mention %s", err, setting)
+		}
+	}
+}
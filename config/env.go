@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix starts the name of every environment variable that changes a setting
+const EnvPrefix = "WOTD_"
+
+// ApplyEnv changes the settings named by environment variables. Each variable is named after the section and
+// setting in upper snake case, eg WOTD_RULES_TARGET_SCORE for rules.targetScore. Lists are comma separated and
+// durations are written like "10s". Room overrides can only be set in a file. Variables are looked up with
+// lookupEnv, which is normally os.LookupEnv.
+func ApplyEnv(config *Config, lookupEnv func(string) (string, bool)) error {
+	sections := reflect.ValueOf(config).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		if section.Kind() != reflect.Struct {
+			continue
+		}
+		sectionName := envName(sections.Type().Field(i))
+
+		for j := 0; j < section.NumField(); j++ {
+			name := EnvPrefix + sectionName + "_" + envName(section.Type().Field(j))
+			value, ok := lookupEnv(name)
+			if !ok {
+				continue
+			}
+			err := setFromString(section.Field(j), value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// envName converts a field's yaml name, eg "targetScore", to upper snake case, eg "TARGET_SCORE"
+func envName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	var envName strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			envName.WriteRune('_')
+		}
+		envName.WriteRune(unicode.ToUpper(r))
+	}
+	return envName.String()
+}
+
+func setFromString(field reflect.Value, value string) error {
+	if duration, ok := field.Addr().Interface().(*Duration); ok {
+		return duration.UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Ptr:
+		target := reflect.New(field.Type().Elem())
+		err := setFromString(target.Elem(), value)
+		if err != nil {
+			return err
+		}
+		field.Set(target)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package deck
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/model"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxUploadBytes limits the size of an uploaded deck
+const maxUploadBytes = 5 << 20
+
+// Handler serves the deck HTTP API. It expects to be mounted with the prefix stripped, eg
+// http.StripPrefix("/decks", handler), and serves:
+//
+//	GET    /         lists the decks and how many words of each type they have
+//	GET    /{name}   returns the words in a deck as JSON
+//	PUT    /{name}   uploads a deck as CSV, JSON or YAML, picked by the Content-Type or the format query parameter
+//	POST   /{name}   same as PUT
+//	DELETE /{name}   deletes a deck
+type Handler struct {
+	store              *Store
+	optionsPerQuestion int
+}
+
+// Summary describes a deck without listing all its words
+type Summary struct {
+	Name        string
+	WordsByType map[string]int
+}
+
+// NewHandler creates the deck HTTP API. Uploaded decks must have at least optionsPerQuestion words of each type.
+func NewHandler(store *Store, optionsPerQuestion int) *Handler {
+	return &Handler{
+		store:              store,
+		optionsPerQuestion: optionsPerQuestion,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(r.URL.Path, "/")
+
+	if name == "" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.listDecks(w)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getDeck(w, name)
+	case http.MethodPut, http.MethodPost:
+		h.uploadDeck(w, r, name)
+	case http.MethodDelete:
+		h.deleteDeck(w, name)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) listDecks(w http.ResponseWriter) {
+	names, err := h.store.Names()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	summaries := make([]Summary, 0, len(names))
+	for _, name := range names {
+		words, err := h.store.Load(name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		summaries = append(summaries, summarise(name, words))
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (h *Handler) getDeck(w http.ResponseWriter, name string) {
+	words, err := h.store.Load(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, words)
+}
+
+func (h *Handler) uploadDeck(w http.ResponseWriter, r *http.Request, name string) {
+	words, err := model.ReadWordList(http.MaxBytesReader(w, r.Body, maxUploadBytes), uploadFormat(r))
+	if err != nil {
+		http.Error(w, "Unable to read the word list: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = Validate(words, h.optionsPerQuestion)
+	if err != nil {
+		http.Error(w, "Invalid deck: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.store.Save(name, words)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	log.Println("Saved deck", name, "with", len(words), "words")
+	writeJSON(w, http.StatusCreated, summarise(name, words))
+}
+
+func (h *Handler) deleteDeck(w http.ResponseWriter, name string) {
+	err := h.store.Delete(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// uploadFormat picks the word list format from the format query parameter, falling back to the Content-Type
+func uploadFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return "csv"
+	case "application/x-yaml", "application/yaml", "text/yaml", "text/x-yaml":
+		return "yaml"
+	default:
+		return "json"
+	}
+}
+
+func summarise(name string, words model.Words) Summary {
+	wordsByType := make(map[string]int)
+	for wordType, wordsOfType := range words.GroupByType() {
+		wordsByType[wordType] = len(wordsOfType)
+	}
+	return Summary{
+		Name:        name,
+		WordsByType: wordsByType,
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case ErrInvalidName:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case ErrBuiltIn:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Println("Deck error:", err)
+		http.Error(w, fmt.Sprint("Internal error: ", err), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		log.Println("Unable to write JSON response:", err)
+	}
+}
@@ -0,0 +1,108 @@
+package deck
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const jargonCSV = `synergy,noun,working together
+bandwidth,noun,capacity to take on work
+deliverable,noun,something to hand over
+`
+
+func newTestServer(t *testing.T) (*Store, *httptest.Server) {
+	t.Helper()
+	store := NewStore(t.TempDir())
+	return store, httptest.NewServer(http.StripPrefix("/decks", NewHandler(store, 3)))
+}
+
+func upload(t *testing.T, server *httptest.Server, name string, contentType string, body string) *http.Response {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodPut, server.URL+"/decks/"+name, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", contentType)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	return response
+}
+
+func TestHandler_UploadCSV(t *testing.T) {
+	store, server := newTestServer(t)
+	defer server.Close()
+
+	response := upload(t, server, "jargon", "text/csv", jargonCSV)
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("Got status %d and expected %d", response.StatusCode, http.StatusCreated)
+	}
+
+	words, err := store.Load("jargon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 3 {
+		t.Errorf("Got %d words and expected 3", len(words))
+	}
+}
+
+func TestHandler_UploadRejectsTooFewWordsPerType(t *testing.T) {
+	_, server := newTestServer(t)
+	defer server.Close()
+
+	body := jargonCSV + "circle back,verb,to return to a topic later\n"
+	response := upload(t, server, "jargon", "text/csv", body)
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Got status %d and expected %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestHandler_UploadRejectsBadJSON(t *testing.T) {
+	_, server := newTestServer(t)
+	defer server.Close()
+
+	response := upload(t, server, "jargon", "application/json", "not json")
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Got status %d and expected %d", response.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_List(t *testing.T) {
+	store, server := newTestServer(t)
+	defer server.Close()
+	store.AddBuiltIn(WordOfTheDay, jargon)
+
+	response, err := http.Get(server.URL + "/decks/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var summaries []Summary
+	if err := json.NewDecoder(response.Body).Decode(&summaries); err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 1 || summaries[0].Name != WordOfTheDay || summaries[0].WordsByType["verb"] != 3 {
+		t.Errorf("Got %v and expected the built-in deck with 3 verbs", summaries)
+	}
+}
+
+func TestHandler_GetMissing(t *testing.T) {
+	_, server := newTestServer(t)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/decks/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("Got status %d and expected %d", response.StatusCode, http.StatusNotFound)
+	}
+}
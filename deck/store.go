@@ -0,0 +1,209 @@
+// Package deck stores named word lists, known as decks, that games can draw their questions from
+package deck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/model"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WordOfTheDay is the name of the built-in deck holding the scraped words of the day
+const WordOfTheDay = "wotd"
+
+var (
+	// ErrNotFound is returned when there is no deck with the given name
+	ErrNotFound = errors.New("deck not found")
+	// ErrBuiltIn is returned when trying to overwrite or delete a built-in deck
+	ErrBuiltIn = errors.New("built-in decks can't be changed")
+	// ErrInvalidName is returned when the deck name isn't allowed
+	ErrInvalidName = errors.New("deck names must be 1-50 letters, digits, dashes or underscores")
+)
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,50}$`)
+
+// Store keeps the decks. Uploaded decks are saved as JSON files in a directory so they survive a restart, and
+// built-in decks, such as the words of the day, are held in memory.
+type Store struct {
+	dir     string
+	lock    sync.RWMutex
+	builtIn map[string]model.Words
+}
+
+// NewStore creates a store that saves uploaded decks to the given directory
+func NewStore(dir string) *Store {
+	return &Store{
+		dir:     dir,
+		builtIn: make(map[string]model.Words),
+	}
+}
+
+// AddBuiltIn adds an in-memory deck that can't be overwritten or deleted through the store
+func (s *Store) AddBuiltIn(name string, words model.Words) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.builtIn[name] = words
+}
+
+// Names returns the names of all the decks, in alphabetical order
+func (s *Store) Names() ([]string, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	names := make([]string, 0, len(s.builtIn))
+	for name := range s.builtIn {
+		names = append(names, name)
+	}
+
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".json")
+		if !file.IsDir() && name != file.Name() && s.builtIn[name] == nil {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load returns the words in the named deck
+func (s *Store) Load(name string) (model.Words, error) {
+	if !ValidName(name) {
+		return nil, ErrInvalidName
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if words, ok := s.builtIn[name]; ok {
+		return words, nil
+	}
+
+	data, err := ioutil.ReadFile(s.deckFile(name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var words model.Words
+	err = json.Unmarshal(data, &words)
+	return words, err
+}
+
+// LoadAll combines the words from all the named decks into one word list
+func (s *Store) LoadAll(names []string) (model.Words, error) {
+	var words model.Words
+	for _, name := range names {
+		deckWords, err := s.Load(name)
+		if err != nil {
+			return nil, fmt.Errorf("deck %q: %w", name, err)
+		}
+		words = append(words, deckWords...)
+	}
+	return words, nil
+}
+
+// Save stores the words as the named deck, replacing any deck that already has that name
+func (s *Store) Save(name string, words model.Words) error {
+	if !ValidName(name) {
+		return ErrInvalidName
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.builtIn[name]; ok {
+		return ErrBuiltIn
+	}
+
+	data, err := json.MarshalIndent(words, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(s.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so a half-written deck is never loaded
+	tempFile := s.deckFile(name) + ".tmp"
+	err = ioutil.WriteFile(tempFile, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempFile, s.deckFile(name))
+}
+
+// Delete removes the named deck
+func (s *Store) Delete(name string) error {
+	if !ValidName(name) {
+		return ErrInvalidName
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.builtIn[name]; ok {
+		return ErrBuiltIn
+	}
+
+	err := os.Remove(s.deckFile(name))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *Store) deckFile(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// ValidName returns true if the name can be used for a deck. Names are used as file names, so they are limited
+// to characters that are safe on any file system.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Validate checks that a deck can be used in a game. Every word needs a word, word type and definition, and every
+// word type needs enough words to fill the options of a question.
+func Validate(words model.Words, optionsPerQuestion int) error {
+	if len(words) == 0 {
+		return errors.New("the deck has no words")
+	}
+
+	for i, word := range words {
+		if strings.TrimSpace(word.Word) == "" ||
+			strings.TrimSpace(word.WordType) == "" ||
+			strings.TrimSpace(word.Definition) == "" {
+			return fmt.Errorf("word %d needs a word, word type and definition", i+1)
+		}
+	}
+
+	var tooFew []string
+	for wordType, wordsOfType := range words.GroupByType() {
+		if len(wordsOfType) < optionsPerQuestion {
+			tooFew = append(tooFew, fmt.Sprintf("%s (%d)", wordType, len(wordsOfType)))
+		}
+	}
+	if len(tooFew) > 0 {
+		sort.Strings(tooFew)
+		return fmt.Errorf("each word type needs at least %d words, but these have fewer: %s",
+			optionsPerQuestion, strings.Join(tooFew, ", "))
+	}
+
+	return nil
+}
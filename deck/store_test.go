@@ -0,0 +1,127 @@
+package deck
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"testing"
+)
+
+var jargon = model.Words{
+	{Word: "synergy", WordType: "noun", Definition: "working together"},
+	{Word: "bandwidth", WordType: "noun", Definition: "capacity to take on work"},
+	{Word: "deliverable", WordType: "noun", Definition: "something to hand over"},
+	{Word: "circle back", WordType: "verb", Definition: "to return to a topic later"},
+	{Word: "action", WordType: "verb", Definition: "to do something"},
+	{Word: "bikeshed", WordType: "verb", Definition: "to argue about trivial details"},
+}
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	err := store.Save("jargon", jargon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Load("jargon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(jargon) || got[0] != jargon[0] {
+		t.Errorf("Got %v and expected %v", got, jargon)
+	}
+}
+
+func TestStore_Names(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.AddBuiltIn(WordOfTheDay, jargon)
+	if err := store.Save("jargon", jargon); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := store.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "jargon" || names[1] != WordOfTheDay {
+		t.Errorf("Got %v and expected [jargon %s]", names, WordOfTheDay)
+	}
+}
+
+func TestStore_LoadAll(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.AddBuiltIn(WordOfTheDay, model.Words{{Word: "serendipity", WordType: "noun", Definition: "luck"}})
+	if err := store.Save("jargon", jargon); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.LoadAll([]string{"jargon", WordOfTheDay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(jargon)+1 {
+		t.Errorf("Got %d words and expected %d", len(got), len(jargon)+1)
+	}
+
+	_, err = store.LoadAll([]string{"jargon", "missing"})
+	if err == nil {
+		t.Error("Expected an error for a missing deck")
+	}
+}
+
+func TestStore_BuiltInIsReadOnly(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.AddBuiltIn(WordOfTheDay, jargon)
+
+	if err := store.Save(WordOfTheDay, jargon); err != ErrBuiltIn {
+		t.Errorf("Got %v and expected %v", err, ErrBuiltIn)
+	}
+	if err := store.Delete(WordOfTheDay); err != ErrBuiltIn {
+		t.Errorf("Got %v and expected %v", err, ErrBuiltIn)
+	}
+}
+
+func TestStore_InvalidName(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	for _, name := range []string{"", "../escape", "has space", "dots.json"} {
+		if err := store.Save(name, jargon); err != ErrInvalidName {
+			t.Errorf("Save(%q): got %v and expected %v", name, err, ErrInvalidName)
+		}
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.Save("jargon", jargon); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Delete("jargon"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("jargon"); err != ErrNotFound {
+		t.Errorf("Got %v and expected %v", err, ErrNotFound)
+	}
+	if err := store.Delete("jargon"); err != ErrNotFound {
+		t.Errorf("Got %v and expected %v", err, ErrNotFound)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(jargon, 3); err != nil {
+		t.Errorf("Expected a valid deck, got %v", err)
+	}
+
+	if err := Validate(jargon, 4); err == nil {
+		t.Error("Expected an error when there are fewer words per type than options per question")
+	}
+
+	if err := Validate(model.Words{}, 3); err == nil {
+		t.Error("Expected an error for an empty deck")
+	}
+
+	missingType := append(model.Words{{Word: "oops", Definition: "no word type"}}, jargon...)
+	if err := Validate(missingType, 3); err == nil {
+		t.Error("Expected an error for a word without a type")
+	}
+}
@@ -0,0 +1,30 @@
+package game
+
+import "time"
+
+// Clock tells the time and sets timers for a game. Tests swap in a fake clock so that whole games can be
+// played without waiting for real time to pass.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// AfterFunc calls f once the duration has passed. The real clock calls f in its own goroutine, but a fake
+	// clock may call it on the goroutine that moves the clock on, so f mustn't wait for that goroutine.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer set by a Clock
+type Timer interface {
+	// Stop prevents the timer from firing. It returns false if the timer has already fired or been stopped.
+	Stop() bool
+}
+
+// RealClock is the Clock that uses real time
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
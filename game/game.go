@@ -1,33 +1,95 @@
 package game
 
 import (
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/icon"
 	"github.com/ksanta/wordofthedaygame/model"
+	"github.com/ksanta/wordofthedaygame/names"
 	"github.com/ksanta/wordofthedaygame/player"
 	"log"
+	"math"
 	"math/rand"
-	"sync"
+	"strings"
 	"time"
 )
 
+// Game runs one game at a time for a group of players. All of the game state is owned by the Run goroutine,
+// which moves the game through its phases as player messages and timer events arrive.
 type Game struct {
-	WordsByType map[string]model.Words
+	WordsByType model.WordsByType
 	// Game rules
 	TargetScore         int
 	OptionsPerQuestion  int
 	DurationPerQuestion time.Duration
 	MaxPlayerCount      int
+	// MixedTypes lets the options in a question have different word types. Normally they all share a type, so
+	// the definitions can't be told apart by grammar alone.
+	MixedTypes bool
+	// CountdownDuration is how long players are warned before the first question
+	CountdownDuration time.Duration
+	// RevealDuration is how long the results of a round are shown before the next question
+	RevealDuration time.Duration
+	// CorrectPoints are earned for a correct answer, and SpeedPoints for answering straight away. The speed
+	// points fall to zero as the time runs out.
+	CorrectPoints int
+	SpeedPoints   int
+	// Names checks the names players pick, and Icons are the icons they can pick from
+	Names *names.Checker
+	Icons *icon.Catalogue
+	// Clock sets the timers for each phase. It can be replaced before Run is called, eg by tests.
+	Clock Clock
 	// Communication
 	MessageChan chan player.PlayerMessage
-	StartChan   chan struct{}
-	// Fields to track game in progress
-	players           player.Players
-	waitGroup         sync.WaitGroup
-	correctAnswer     int
-	gameInProgress    bool
-	waitingForAnswers bool
+	// StartChan starts the game no matter who asks. Start only starts it for the host.
+	StartChan     chan struct{}
+	startRequests chan startRequest
+	// timerChan receives the timer events. Each event carries the timer ID it was set with.
+	timerChan chan int
+	// wordsChan receives new words to replace WordsByType
+	wordsChan chan model.WordsByType
+	// stateChan receives requests for the game's State, and watchChan and unwatchChan add and remove watchers
+	stateChan   chan chan State
+	watchChan   chan chan model.MessageToPlayer
+	unwatchChan chan chan model.MessageToPlayer
+	watchers    map[chan model.MessageToPlayer]bool
+	// Fields to track game in progress. These are only touched by the Run goroutine.
+	players player.Players
+	phase   Phase
+	// host is the player who can start the game, using hostToken. It is the first player to join, or the next
+	// one if they leave.
+	host          *player.Player
+	hostToken     string
+	correctAnswer int
+	// questionID identifies the current question. It goes up with every question, so IDs are never reused.
+	questionID int
+	// question is the current question, or nil between games
+	question *model.PresentQuestion
+	// timer is the current timer, and timerID identifies it. A timer that fires just as the phase changes
+	// has an older ID and is ignored.
+	timer   Timer
+	timerID int
+	// questionStartTime is when the current question was sent, to work out how quickly players answer
+	questionStartTime time.Time
+	// shutdownChan receives requests to shut down, and graceChan hears when the grace period is up
+	shutdownChan chan shutdownRequest
+	graceChan    chan struct{}
+	// shuttingDown is set once a shutdown is requested. No new players can join after that.
+	shuttingDown bool
+	// shutdownDone is closed once the players are closed. It is nil when no shutdown is pending.
+	shutdownDone  chan struct{}
+	shutdownTimer Timer
 }
 
-func NewGame(wordsByType map[string]model.Words,
+// shutdownRequest asks the game to shut down, giving a game in progress up to grace to finish
+type shutdownRequest struct {
+	grace time.Duration
+	done  chan struct{}
+}
+
+// shutdownMessage is sent to players still connected when the server shuts down
+const shutdownMessage = "The server is shutting down"
+
+func NewGame(wordsByType model.WordsByType,
 	targetScore int,
 	optionsPerQuestion int,
 	durationPerQuestion time.Duration,
@@ -40,14 +102,27 @@ func NewGame(wordsByType map[string]model.Words,
 		OptionsPerQuestion:  optionsPerQuestion,
 		DurationPerQuestion: durationPerQuestion,
 		MaxPlayerCount:      maxPlayerCount,
+		CountdownDuration:   5 * time.Second,
+		RevealDuration:      2 * time.Second,
+		CorrectPoints:       100,
+		SpeedPoints:         50,
+		Names:               names.NewChecker(names.DefaultMaxLength, names.DefaultBlockedWords),
+		Icons:               icon.NewCatalogue(icon.Horses...),
+		Clock:               RealClock{},
 		MessageChan:         make(chan player.PlayerMessage),
-		// Buffer on StartChan required because same thread can send/receive
-		StartChan:         make(chan struct{}, 1),
-		players:           make([]*player.Player, 0, 10),
-		waitGroup:         sync.WaitGroup{},
-		correctAnswer:     -1,
-		gameInProgress:    false,
-		waitingForAnswers: false,
+		StartChan:           make(chan struct{}, 1),
+		startRequests:       make(chan startRequest),
+		timerChan:           make(chan int),
+		wordsChan:           make(chan model.WordsByType),
+		stateChan:           make(chan chan State),
+		watchChan:           make(chan chan model.MessageToPlayer),
+		unwatchChan:         make(chan chan model.MessageToPlayer),
+		watchers:            make(map[chan model.MessageToPlayer]bool),
+		shutdownChan:        make(chan shutdownRequest),
+		graceChan:           make(chan struct{}, 1),
+		players:             make([]*player.Player, 0, 10),
+		phase:               Lobby,
+		correctAnswer:       -1,
 	}
 }
 
@@ -61,7 +136,7 @@ func (game *Game) Run() {
 				game.handlePlayerReady(playerMessage)
 
 			case playerMessage.Message.PlayerResponse != nil:
-				game.handlePlayerResponse(playerMessage.Player, playerMessage.Message.PlayerResponse.Response)
+				game.handlePlayerResponse(playerMessage.Player, playerMessage.Message.PlayerResponse)
 
 			case playerMessage.Message.Disconnected != nil:
 				// Player sent the game a Disconnect msg because the connection was lost
@@ -69,31 +144,205 @@ func (game *Game) Run() {
 			}
 
 		case <-game.StartChan:
-			if game.players.NumActivePlayers() > 0 {
-				go game.PlayGame()
+			game.start()
+
+		case request := <-game.startRequests:
+			request.reply <- game.handleStartRequest(request)
+
+		case timerID := <-game.timerChan:
+			if timerID == game.timerID {
+				game.handleTimer()
 			}
+
+		case wordsByType := <-game.wordsChan:
+			// Questions already asked keep their words. The new words are used from the next question.
+			game.WordsByType = wordsByType
+
+		case reply := <-game.stateChan:
+			reply <- game.state()
+
+		case watcher := <-game.watchChan:
+			game.watchers[watcher] = true
+
+		case watcher := <-game.unwatchChan:
+			game.unwatch(watcher)
+
+		case request := <-game.shutdownChan:
+			game.beginShutdown(request)
+
+		case <-game.graceChan:
+			game.endGameForShutdown()
+		}
+	}
+}
+
+// UpdateWords replaces the words that questions are picked from, eg as more words are scraped. Run must be
+// running.
+func (game *Game) UpdateWords(wordsByType model.WordsByType) {
+	game.wordsChan <- wordsByType
+}
+
+// Shutdown stops the game. A game in progress is allowed to finish, but once grace has passed the players are
+// told the server is shutting down and sent a final summary. The returned channel is closed once every player
+// has been closed. Shutdown must only be called once, and Run must be running.
+func (game *Game) Shutdown(grace time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	game.shutdownChan <- shutdownRequest{grace: grace, done: done}
+	return done
+}
+
+func (game *Game) beginShutdown(request shutdownRequest) {
+	game.shuttingDown = true
+	game.shutdownDone = request.done
+
+	if !game.phase.isActive() {
+		game.sendErrorToPlayers(model.ErrorShuttingDown, shutdownMessage)
+		game.closeForShutdown()
+		return
+	}
+
+	log.Println("Waiting up to", request.grace, "for the game to finish before shutting down")
+	game.shutdownTimer = game.Clock.AfterFunc(request.grace, func() {
+		game.graceChan <- struct{}{}
+	})
+}
+
+// endGameForShutdown ends a game that is still going when the grace period is up
+func (game *Game) endGameForShutdown() {
+	if !game.phase.isActive() {
+		return
+	}
+
+	log.Println("Ending the game early to shut down")
+	game.sendErrorToPlayers(model.ErrorShuttingDown, shutdownMessage)
+	game.enterPhase(Finished)
+	game.sendGameSummaryToPlayers()
+	game.closeForShutdown()
+}
+
+// closeForShutdown closes every player's connection, telling them the server is going away, and then lets
+// Shutdown return
+func (game *Game) closeForShutdown() {
+	closePlayer := func(p *player.Player) {
+		p.Active = false
+		p.CloseGoingAway(shutdownMessage)
+	}
+	game.players.ForActivePlayers(closePlayer)
+	game.reset()
+	game.unwatchAll()
+
+	if game.shutdownDone != nil {
+		if game.shutdownTimer != nil {
+			game.shutdownTimer.Stop()
 		}
+		close(game.shutdownDone)
+		game.shutdownDone = nil
+	}
+}
+
+// setTimer delivers a timer event to the Run goroutine after the duration. Setting a new timer, or changing
+// phase, cancels the old one.
+func (game *Game) setTimer(duration time.Duration) {
+	game.stopTimer()
+	timerID := game.timerID
+	game.timer = game.Clock.AfterFunc(duration, func() {
+		game.timerChan <- timerID
+	})
+}
+
+func (game *Game) stopTimer() {
+	if game.timer != nil {
+		game.timer.Stop()
+		game.timer = nil
+	}
+	game.timerID++
+}
+
+// enterPhase moves the game to a new phase, cancelling any timer set in the old phase
+func (game *Game) enterPhase(phase Phase) {
+	log.Println("Game phase:", game.phase, "->", phase)
+	if !game.phase.isActive() && phase.isActive() {
+		activeGames.Inc()
+	} else if game.phase.isActive() && !phase.isActive() {
+		activeGames.Dec()
+	}
+	game.phase = phase
+	game.stopTimer()
+}
+
+// handleTimer moves the game on when the time for the current phase runs out
+func (game *Game) handleTimer() {
+	switch game.phase {
+	case Countdown:
+		game.askQuestion()
+	case Question:
+		game.revealAnswer()
+	case Reveal:
+		game.askQuestion()
 	}
 }
 
 func (game *Game) handlePlayerReady(playerMessage player.PlayerMessage) {
+	if game.shuttingDown {
+		playerMessage.Player.Send(model.MessageToPlayer{
+			Error: &model.GameError{Code: model.ErrorShuttingDown, Message: shutdownMessage},
+		})
+		return
+	}
+
 	// Prevent player from registering if there is a game in progress
-	if game.gameInProgress {
+	if game.phase != Lobby {
 		messageToPlayer := model.MessageToPlayer{
 			Error: &model.GameError{
-				Message: "Game is already in progress",
+				Code:       model.ErrorGameInProgress,
+				Message:    "Game is already in progress",
+				RetryAfter: int(math.Ceil(game.estimatedTimeLeft().Seconds())),
 			},
 		}
-		playerMessage.Player.SendToClientChan <- messageToPlayer
+		playerMessage.Player.Send(messageToPlayer)
 		return
 	}
 
-	// Player has sent their name - they are ready to play
+	// Player has sent their name - they are ready to play, unless they already are
 	p := playerMessage.Player
-	p.SetName(playerMessage.Message.PlayerDetailsResp.Name)
-	p.Icon = playerMessage.Message.PlayerDetailsResp.Icon
+	if game.hasJoined(p) {
+		return
+	}
+	details := *playerMessage.Message.PlayerDetailsResp
+	if details.Name == "" {
+		details.Name = p.AccountDetails.Name
+	}
+	if details.Icon == "" {
+		details.Icon = p.AccountDetails.Icon
+	}
+
+	name, err := game.Names.Check(details.Name)
+	if err != nil {
+		p.Send(model.MessageToPlayer{
+			Error: &model.GameError{Code: model.ErrorInvalidName, Message: "That name can't be used: " + err.Error()},
+		})
+		return
+	}
+	if details.Icon == "" {
+		details.Icon = game.unusedIcon()
+	} else if !game.Icons.Has(details.Icon) {
+		p.Send(model.MessageToPlayer{
+			Error: &model.GameError{
+				Code:    model.ErrorInvalidIcon,
+				Message: fmt.Sprintf("There is no icon called %q", details.Icon),
+			},
+		})
+		return
+	}
+
+	// Players with the same name couldn't be told apart, so later ones get a number after their name
+	p.SetName(game.Names.Unique(name, game.nameTaken))
+	p.Icon = details.Icon
 	p.Active = true
 	game.players = append(game.players, p)
+	if game.host == nil {
+		game.makeHost(p)
+	}
 	game.sendWelcomeToPlayer(p)
 
 	// Sending round summary now alerts each player when new players join
@@ -101,20 +350,94 @@ func (game *Game) handlePlayerReady(playerMessage player.PlayerMessage) {
 
 	// Auto-start the game if there are N players ready
 	if game.players.NumActivePlayers() == game.MaxPlayerCount {
-		game.StartChan <- struct{}{}
+		game.start()
 	}
 }
 
-func (game *Game) safelyUnregisterPlayer(p *player.Player) {
-	close(p.SendToClientChan)
-	if game.waitingForAnswers {
-		game.waitGroup.Done()
+// hasJoined returns true if the player has already joined the game
+func (game *Game) hasJoined(p *player.Player) bool {
+	for _, joined := range game.players {
+		if joined == p {
+			return true
+		}
+	}
+	return false
+}
+
+// nameTaken returns true if an active player already has the name, ignoring case
+func (game *Game) nameTaken(name string) bool {
+	for _, p := range game.players {
+		if p.Active && strings.EqualFold(p.GetName(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// unusedIcon returns the first icon in the catalogue that no active player has, for players who don't pick one.
+// If every icon is taken, they have to share.
+func (game *Game) unusedIcon() string {
+	icons := game.Icons.Icons()
+	for _, candidate := range icons {
+		used := false
+		for _, p := range game.players {
+			used = used || (p.Active && p.Icon == candidate.ID)
+		}
+		if !used {
+			return candidate.ID
+		}
+	}
+	if len(icons) > 0 {
+		return icons[0].ID
+	}
+	return ""
+}
+
+// estimatedTimeLeft guesses how long the game in progress has left. It works out the fewest questions the
+// leader needs to win, and allows each of them its full time.
+func (game *Game) estimatedTimeLeft() time.Duration {
+	if !game.phase.isActive() {
+		return 0
+	}
+
+	pointsLeft := game.TargetScore
+	if leader := game.players.PlayerWithHighestPoints(); leader != nil {
+		pointsLeft -= leader.GetPoints()
+	}
+	questionsLeft := 1
+	if maxPoints := game.CorrectPoints + game.SpeedPoints; maxPoints > 0 && pointsLeft > maxPoints {
+		questionsLeft = (pointsLeft + maxPoints - 1) / maxPoints
+	}
+
+	timeLeft := time.Duration(questionsLeft) * (game.DurationPerQuestion + game.RevealDuration)
+	if game.phase == Countdown {
+		timeLeft += game.CountdownDuration
 	}
+	return timeLeft
+}
+
+func (game *Game) safelyUnregisterPlayer(p *player.Player) {
+	p.Close()
 	p.Active = false
+	p.WaitingForResponse = false
 
 	// Reset the game if all players have become inactive
 	if game.players.AllInactive() {
-		game.reset()
+		if game.shuttingDown {
+			game.closeForShutdown()
+		} else {
+			game.reset()
+		}
+		return
+	}
+
+	if p == game.host {
+		game.handOverHost()
+	}
+
+	// The player who left might have been the last one we were waiting on
+	if game.phase == Question && game.players.NumWaitingForResponse() == 0 {
+		game.revealAnswer()
 	}
 }
 
@@ -122,152 +445,244 @@ func (game *Game) requestPlayerName(p *player.Player) {
 	message := model.MessageToPlayer{
 		PlayerDetailsReq: &model.PlayerDetailsReq{},
 	}
-	p.SendToClientChan <- message
+	p.Send(message)
 }
 
 func (game *Game) sendWelcomeToPlayer(p *player.Player) {
-	// Sending messages to the player must be done via channel
-	p.SendToClientChan <- model.MessageToPlayer{
-		Welcome: &model.Welcome{TargetScore: game.TargetScore},
+	// Messages to the player are queued, so sending never blocks the game
+	welcome := &model.Welcome{TargetScore: game.TargetScore, Name: p.GetName(), Icon: p.Icon}
+	if p == game.host {
+		welcome.HostToken = game.hostToken
 	}
+	p.Send(model.MessageToPlayer{Welcome: welcome})
 }
 
-func (game *Game) AlertPlayersGameWillBegin() {
-	const waitSeconds = 5
+// start begins the countdown to the first question, unless the game has already started or has no players
+func (game *Game) start() error {
+	if game.phase != Lobby {
+		return ErrAlreadyStarted
+	}
+	if game.players.NumActivePlayers() == 0 {
+		return ErrNoPlayers
+	}
+
+	log.Println("Starting game")
+	gamesStarted.Inc()
+	game.enterPhase(Countdown)
 
+	aboutToStart := model.MessageToPlayer{
+		AboutToStart: &model.AboutToStart{
+			Seconds: int(game.CountdownDuration.Seconds()),
+		},
+	}
 	alertPlayers := func(p *player.Player) {
-		p.SendToClientChan <- model.MessageToPlayer{
-			AboutToStart: &model.AboutToStart{
-				Seconds: waitSeconds,
-			},
-		}
+		p.Send(aboutToStart)
 	}
 	game.players.ForActivePlayers(alertPlayers)
+	game.notifyWatchers(aboutToStart)
 
-	time.Sleep(time.Duration(waitSeconds) * time.Second)
+	game.setTimer(game.CountdownDuration)
+	return nil
 }
 
-// PlayGame orchestrates the rounds of questions and displays the result to all players.
-// This runs in a goroutine.
-func (game *Game) PlayGame() {
-	log.Println("Starting game")
+// askQuestion sends a new question to every player, and waits for their answers
+func (game *Game) askQuestion() {
+	wordsInThisRound, err := game.PickWordsForQuestion()
+	if err != nil {
+		log.Println("Ending game early:", err)
+		game.sendErrorToPlayers(model.ErrorNoWords, err.Error())
+		game.finish()
+		return
+	}
 
-	game.gameInProgress = true
-	game.AlertPlayersGameWillBegin()
+	game.enterPhase(Question)
+	game.correctAnswer = wordsInThisRound.PickRandomIndex()
+	game.questionID++
+
+	game.question = &model.PresentQuestion{
+		QuestionID:     game.questionID,
+		WordToGuess:    wordsInThisRound[game.correctAnswer].Word,
+		Definitions:    wordsInThisRound.GetDefinitions(),
+		SecondsAllowed: int(game.DurationPerQuestion.Seconds()),
+	}
+	questionMsg := model.MessageToPlayer{PresentQuestion: game.question}
+
+	game.questionStartTime = game.Clock.Now()
+	sendQuestion := func(p *player.Player) {
+		p.Send(questionMsg)
+		p.WaitingForResponse = true
+	}
+	game.players.ForActivePlayers(sendQuestion)
+	game.notifyWatchers(questionMsg)
 
-	maxScore := 0
-	for maxScore < game.TargetScore {
-		game.playRound()
+	// Players who haven't answered by the end of the time allowed miss out. A little extra time is allowed
+	// for the answers to get here over the network.
+	game.setTimer(game.DurationPerQuestion + time.Second)
+}
 
-		if game.players.AllInactive() {
-			break
+// revealAnswer ends the question. Players who didn't answer are told the correct answer, and everyone is
+// shown the scores.
+func (game *Game) revealAnswer() {
+	game.enterPhase(Reveal)
+
+	tooSlow := func(p *player.Player) {
+		if p.WaitingForResponse {
+			p.WaitingForResponse = false
+			answers.WithLabelValues(answerTimeout).Inc()
+			p.Send(model.MessageToPlayer{
+				PlayerResult: &model.PlayerResult{
+					QuestionID:    game.questionID,
+					Correct:       false,
+					Points:        0,
+					CorrectAnswer: game.correctAnswer,
+				},
+			})
 		}
+	}
+	game.players.ForActivePlayers(tooSlow)
 
-		maxScore = game.players.PlayerWithHighestPoints().GetPoints()
-		time.Sleep(2 * time.Second) // Give the players time to prepare for the next round
+	game.sendRoundSummaryToEachPlayer()
+
+	if game.players.PlayerWithHighestPoints().GetPoints() >= game.TargetScore {
+		game.finish()
+		return
 	}
 
+	// Give the players time to prepare for the next round
+	game.setTimer(game.RevealDuration)
+}
+
+// finish tells the players who won and gets ready for a new game
+func (game *Game) finish() {
+	game.enterPhase(Finished)
+	gamesFinished.Inc()
 	game.sendGameSummaryToPlayers()
-	game.gameInProgress = false
+	if game.shuttingDown {
+		game.closeForShutdown()
+		return
+	}
 	game.reset()
 }
 
-func (game *Game) playRound() {
-	game.sendQuestionToEachPlayer()
-
-	// Wait for all players to send in their responses
-	game.waitingForAnswers = true
-	game.waitGroup.Wait()
-	game.waitingForAnswers = false
+func (game *Game) sendErrorToPlayers(code model.ErrorCode, message string) {
+	gameError := model.MessageToPlayer{
+		Error: &model.GameError{
+			Code:    code,
+			Message: message,
+		},
+	}
+	sendError := func(p *player.Player) {
+		p.Send(gameError)
+	}
 
-	game.sendRoundSummaryToEachPlayer()
+	game.players.ForActivePlayers(sendError)
+	game.notifyWatchers(gameError)
 }
 
 func (game *Game) sendGameSummaryToPlayers() {
 	winner := game.players.PlayerWithHighestPoints()
 
+	summary := model.MessageToPlayer{
+		Summary: &model.Summary{
+			Winner:      winner.GetName(),
+			Icon:        winner.Icon,
+			TotalPoints: winner.GetPoints(),
+			Account:     winner.Account,
+		},
+	}
 	sendSummary := func(p *player.Player) {
-		p.SendToClientChan <- model.MessageToPlayer{
-			Summary: &model.Summary{
-				Winner:      winner.GetName(),
-				Icon:        winner.Icon,
-				TotalPoints: winner.GetPoints(),
-			},
-		}
+		p.Send(summary)
 	}
 
 	game.players.ForActivePlayers(sendSummary)
+	game.notifyWatchers(summary)
 }
 
-func (game *Game) sendQuestionToEachPlayer() {
-	wordType := model.PickRandomType()
-	wordsInThisRound := game.WordsByType[wordType].PickRandomWords(game.OptionsPerQuestion)
-	game.correctAnswer = wordsInThisRound.PickRandomIndex()
-
-	// Wait group keeps track of how many responses to wait for
-	game.waitGroup.Add(game.players.NumActivePlayers())
-
-	questionMsg := model.MessageToPlayer{
-		PresentQuestion: &model.PresentQuestion{
-			WordToGuess:    wordsInThisRound[game.correctAnswer].Word,
-			Definitions:    wordsInThisRound.GetDefinitions(),
-			SecondsAllowed: int(game.DurationPerQuestion.Seconds()),
-		},
+// PickWordsForQuestion picks the options for a question. Only word types with enough words to fill every
+// option are used, unless MixedTypes is on, in which case any words can be picked.
+func (game *Game) PickWordsForQuestion() (model.Words, error) {
+	if game.MixedTypes {
+		allWords := game.WordsByType.All()
+		if len(allWords) < game.OptionsPerQuestion {
+			return nil, fmt.Errorf("need at least %d words but only have %d", game.OptionsPerQuestion, len(allWords))
+		}
+		return allWords.PickRandomWords(game.OptionsPerQuestion), nil
 	}
 
-	sendQuestion := func(p *player.Player) {
-		p.StartTimer()
-		p.SendToClientChan <- questionMsg
-		p.WaitingForResponse = true
+	wordType, ok := game.WordsByType.PickRandomType(game.OptionsPerQuestion)
+	if !ok {
+		return nil, fmt.Errorf("no word type has the %d words needed for a question", game.OptionsPerQuestion)
 	}
-
-	game.players.ForActivePlayers(sendQuestion)
+	return game.WordsByType[wordType].PickRandomWords(game.OptionsPerQuestion), nil
 }
 
 func (game *Game) sendRoundSummaryToEachPlayer() {
-
-	playerStates := make([]model.PlayerState, 0, len(game.players))
-
-	for _, p := range game.players {
-		playerStates = append(playerStates, p.PlayerState())
-	}
-
 	roundSummary := model.MessageToPlayer{
 		RoundSummary: &model.RoundSummary{
-			PlayerStates: playerStates,
+			PlayerStates: game.playerStates(),
 		},
 	}
 
 	sendRoundSummary := func(p *player.Player) {
-		p.SendToClientChan <- roundSummary
+		p.Send(roundSummary)
 	}
 
 	game.players.ForActivePlayers(sendRoundSummary)
+	game.notifyWatchers(roundSummary)
+}
+
+func (game *Game) playerStates() []model.PlayerState {
+	playerStates := make([]model.PlayerState, 0, len(game.players))
+
+	for _, p := range game.players {
+		playerStates = append(playerStates, p.PlayerState())
+	}
+	return playerStates
 }
 
-func (game *Game) handlePlayerResponse(p *player.Player, response int) {
+func (game *Game) handlePlayerResponse(p *player.Player, response *model.PlayerResponse) {
+	if game.phase != Question || response.QuestionID != game.questionID {
+		// The answer arrived too late, or is for a question that was never asked
+		p.Send(model.MessageToPlayer{
+			Error: &model.GameError{
+				Code:    model.ErrorQuestionClosed,
+				Message: fmt.Sprintf("Question %d is not open for answers", response.QuestionID),
+			},
+		})
+		return
+	}
 	if !p.WaitingForResponse {
-		// Reject multiple responses from the player
+		// Ignore the player changing their answer
 		return
 	}
 
-	correct := response == game.correctAnswer
-	elapsedTime := p.StopTimer()
+	correct := response.Response == game.correctAnswer
+	elapsedTime := game.Clock.Now().Sub(game.questionStartTime)
 	points := game.calculatePoints(correct, elapsedTime)
 	p.AddPoints(points)
+	p.WaitingForResponse = false
+
+	answerLatency.Observe(elapsedTime.Seconds())
+	if correct {
+		answers.WithLabelValues(answerCorrect).Inc()
+	} else {
+		answers.WithLabelValues(answerWrong).Inc()
+	}
 
 	// Immediately send the result to the player
-	p.SendToClientChan <- model.MessageToPlayer{
+	p.Send(model.MessageToPlayer{
 		PlayerResult: &model.PlayerResult{
+			QuestionID:    game.questionID,
 			Correct:       correct,
 			Points:        points,
 			CorrectAnswer: game.correctAnswer,
 		},
-	}
-
-	game.waitGroup.Done()
+	})
 
-	p.WaitingForResponse = false
+	// Move on as soon as everyone has answered
+	if game.players.NumWaitingForResponse() == 0 {
+		game.revealAnswer()
+	}
 }
 
 func (game *Game) calculatePoints(correct bool, elapsedTime time.Duration) int {
@@ -278,10 +693,10 @@ func (game *Game) calculatePoints(correct bool, elapsedTime time.Duration) int {
 
 	correctPoints := 0
 	if correct {
-		correctPoints += 100
+		correctPoints += game.CorrectPoints
 	}
 
-	timePoints := int(50 * (game.DurationPerQuestion - elapsedTime) / game.DurationPerQuestion)
+	timePoints := int(time.Duration(game.SpeedPoints) * (game.DurationPerQuestion - elapsedTime) / game.DurationPerQuestion)
 	if timePoints < 0 {
 		timePoints = 0
 	}
@@ -289,8 +704,13 @@ func (game *Game) calculatePoints(correct bool, elapsedTime time.Duration) int {
 	return correctPoints + timePoints
 }
 
-// reset will reset the game state
+// reset will reset the game state, ready for a new game
 func (game *Game) reset() {
 	log.Println("Removing all players")
 	game.players = make([]*player.Player, 0, 10)
+	game.host = nil
+	game.hostToken = ""
+	game.correctAnswer = -1
+	game.question = nil
+	game.enterPhase(Lobby)
 }
@@ -0,0 +1,573 @@
+package game_test
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/ksanta/wordofthedaygame/game"
+	"github.com/ksanta/wordofthedaygame/game/gametest"
+	"github.com/ksanta/wordofthedaygame/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestGame creates a game where a correct answer in no time scores 150, so four rounds wins
+func newTestGame() *game.Game {
+	words := gametest.Words(5, "noun", "verb")
+	return game.NewGame(words.GroupByType(), 500, 3, 10*time.Second, 7)
+}
+
+// startGame starts the game and moves past the countdown to the first question
+func startGame(t *testing.T, h *gametest.Harness, players ...*gametest.FakePlayer) []*model.PresentQuestion {
+	t.Helper()
+	h.Start()
+	for _, fp := range players {
+		fp.Expect(gametest.IsAboutToStart)
+	}
+	h.Clock.FireNext(t)
+	return expectQuestion(t, players...)
+}
+
+func expectQuestion(t *testing.T, players ...*gametest.FakePlayer) []*model.PresentQuestion {
+	t.Helper()
+	questions := make([]*model.PresentQuestion, len(players))
+	for i, fp := range players {
+		questions[i] = fp.Expect(gametest.IsPresentQuestion).PresentQuestion
+	}
+	return questions
+}
+
+// metricValue reads a counter or gauge from the default Prometheus registry. The label value is ignored for
+// metrics without labels.
+func metricValue(t *testing.T, name string, labelValue string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if len(metric.GetLabel()) > 0 && metric.GetLabel()[0].GetValue() != labelValue {
+				continue
+			}
+			if metric.GetCounter() != nil {
+				return metric.GetCounter().GetValue()
+			}
+			return metric.GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestGame_PlayToTargetScore(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	startedBefore := metricValue(t, "wordofthedaygame_games_started_total", "")
+	finishedBefore := metricValue(t, "wordofthedaygame_games_finished_total", "")
+	correctBefore := metricValue(t, "wordofthedaygame_answers_total", "correct")
+	wrongBefore := metricValue(t, "wordofthedaygame_answers_total", "wrong")
+
+	players := h.JoinAll("alice", "bob")
+	alice, bob := players[0], players[1]
+	questions := startGame(t, h, alice, bob)
+
+	for round := 1; ; round++ {
+		if questions[0].WordToGuess != questions[1].WordToGuess {
+			t.Fatalf("Round %d: players got different questions", round)
+		}
+
+		alice.Answer(gametest.CorrectOption(questions[0]))
+		result := alice.Expect(gametest.IsPlayerResult).PlayerResult
+		if !result.Correct || result.Points != 150 {
+			t.Errorf("Round %d: alice got %+v and expected a correct answer worth 150", round, result)
+		}
+
+		bob.Answer(gametest.WrongOption(questions[1]))
+		result = bob.Expect(gametest.IsPlayerResult).PlayerResult
+		if result.Correct || result.Points != 50 {
+			t.Errorf("Round %d: bob got %+v and expected a wrong answer worth 50", round, result)
+		}
+
+		for _, fp := range players {
+			summary := fp.Expect(gametest.IsRoundSummary).RoundSummary
+			if summary.PlayerStates[0].Score != 150*round || summary.PlayerStates[1].Score != 50*round {
+				t.Errorf("Round %d: got scores %+v", round, summary.PlayerStates)
+			}
+		}
+
+		if round == 4 {
+			break
+		}
+		h.Clock.FireNext(t)
+		questions = expectQuestion(t, alice, bob)
+	}
+
+	for _, fp := range players {
+		summary := fp.Expect(gametest.IsSummary).Summary
+		if summary.Winner != "alice" || summary.TotalPoints != 600 {
+			t.Errorf("%s got summary %+v and expected alice to win with 600", fp.Name, summary)
+		}
+	}
+
+	if started := metricValue(t, "wordofthedaygame_games_started_total", "") - startedBefore; started != 1 {
+		t.Errorf("Got %v games started and expected 1", started)
+	}
+	if finished := metricValue(t, "wordofthedaygame_games_finished_total", "") - finishedBefore; finished != 1 {
+		t.Errorf("Got %v games finished and expected 1", finished)
+	}
+	correct := metricValue(t, "wordofthedaygame_answers_total", "correct") - correctBefore
+	wrong := metricValue(t, "wordofthedaygame_answers_total", "wrong") - wrongBefore
+	if correct != 4 || wrong != 4 {
+		t.Errorf("Got %v correct and %v wrong answers and expected 4 of each", correct, wrong)
+	}
+}
+
+func TestGame_PointsDependOnTime(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	alice := h.JoinAll("alice")[0]
+	question := startGame(t, h, alice)[0]
+
+	h.Clock.Advance(4 * time.Second)
+	alice.Answer(gametest.CorrectOption(question))
+	result := alice.Expect(gametest.IsPlayerResult).PlayerResult
+	if result.Points != 130 {
+		t.Errorf("Got %d points and expected 130", result.Points)
+	}
+}
+
+func TestGame_QuestionTimesOut(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	players := h.JoinAll("alice", "bob")
+	alice, bob := players[0], players[1]
+	questions := startGame(t, h, alice, bob)
+
+	alice.Answer(gametest.CorrectOption(questions[0]))
+	alice.Expect(gametest.IsPlayerResult)
+
+	// Bob never answers, so the question closes when the time is up
+	waited := h.Clock.FireNext(t)
+	if waited < 10*time.Second {
+		t.Errorf("Question closed after %s, before the time allowed", waited)
+	}
+
+	result := bob.Expect(gametest.IsPlayerResult).PlayerResult
+	if result.Correct || result.Points != 0 || result.CorrectAnswer != gametest.CorrectOption(questions[1]) {
+		t.Errorf("Bob got %+v and expected no points and the correct answer", result)
+	}
+	for _, fp := range players {
+		fp.Expect(gametest.IsRoundSummary)
+	}
+
+	// A late answer is rejected
+	bob.Answer(gametest.CorrectOption(questions[1]))
+	bob.Expect(gametest.IsErrorCode(model.ErrorQuestionClosed))
+	h.Clock.FireNext(t)
+	bob.Expect(gametest.IsPresentQuestion)
+}
+
+func TestGame_AnswerToPreviousQuestionIsRejected(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	alice := h.JoinAll("alice")[0]
+	first := startGame(t, h, alice)[0]
+	alice.Answer(gametest.CorrectOption(first))
+	alice.Expect(gametest.IsPlayerResult)
+	alice.Expect(gametest.IsRoundSummary)
+
+	h.Clock.FireNext(t)
+	second := expectQuestion(t, alice)[0]
+	if second.QuestionID == first.QuestionID {
+		t.Fatalf("Got question ID %d for both questions", first.QuestionID)
+	}
+
+	// An answer to the first question arriving after the second was asked isn't scored against the second
+	alice.AnswerQuestion(first.QuestionID, gametest.CorrectOption(second))
+	alice.Expect(gametest.IsErrorCode(model.ErrorQuestionClosed))
+
+	// The second question is still open for the real answer
+	alice.Answer(gametest.WrongOption(second))
+	result := alice.Expect(gametest.IsPlayerResult).PlayerResult
+	if result.Correct || result.QuestionID != second.QuestionID {
+		t.Errorf("Got %+v and expected a wrong answer to question %d", result, second.QuestionID)
+	}
+}
+
+func TestGame_DisconnectMidRound(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	players := h.JoinAll("alice", "bob")
+	alice, bob := players[0], players[1]
+	questions := startGame(t, h, alice, bob)
+
+	alice.Answer(gametest.CorrectOption(questions[0]))
+	alice.Expect(gametest.IsPlayerResult)
+
+	// Bob was the only player left to answer, so the round ends as soon as he leaves
+	bob.Disconnect()
+	summary := alice.Expect(gametest.IsRoundSummary).RoundSummary
+	if summary.PlayerStates[1].Active {
+		t.Errorf("Expected bob to be inactive, got %+v", summary.PlayerStates[1])
+	}
+
+	// The game carries on without him
+	h.Clock.FireNext(t)
+	alice.Expect(gametest.IsPresentQuestion)
+}
+
+func TestGame_JoinDuringGameIsRejected(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	alice := h.JoinAll("alice")[0]
+	startGame(t, h, alice)
+
+	latecomer := h.Connect("latecomer")
+	latecomer.Send(model.MessageFromPlayer{
+		PlayerDetailsResp: &model.PlayerDetails{Name: "latecomer"},
+	})
+	gameError := latecomer.Expect(gametest.IsErrorCode(model.ErrorGameInProgress)).Error
+
+	// Alice needs four more questions to win, each taking up to ten seconds plus two to show the results
+	if gameError.RetryAfter != 48 {
+		t.Errorf("Got retry after %d and expected 48", gameError.RetryAfter)
+	}
+}
+
+func TestGame_EveryoneLeavingResetsTheGame(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	players := h.JoinAll("alice", "bob")
+	startGame(t, h, players...)
+
+	for _, fp := range players {
+		fp.Disconnect()
+		fp.ExpectClosed()
+	}
+
+	// Once the game has noticed everyone has gone, a new game can be joined
+	deadline := time.Now().Add(gametest.MessageTimeout)
+	for {
+		carol := h.Connect("carol")
+		carol.Send(model.MessageFromPlayer{
+			PlayerDetailsResp: &model.PlayerDetails{Name: "carol"},
+		})
+		if gametest.IsWelcome(carol.Next()) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the game to reset once every player left")
+		}
+		carol.Disconnect()
+	}
+}
+
+func TestGame_StalledPlayerDoesNotHoldUpOthers(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	players := h.JoinAll("alice", "bob")
+	alice, bob := players[0], players[1]
+
+	// Bob's client hangs. He stops reading and never answers.
+	bob.Stall()
+
+	question := startGame(t, h, alice)[0]
+	for round := 1; round <= 4; round++ {
+		alice.Answer(gametest.CorrectOption(question))
+		alice.Expect(gametest.IsPlayerResult)
+
+		// The question times out waiting for bob
+		h.Clock.FireNext(t)
+		alice.Expect(gametest.IsRoundSummary)
+
+		if round < 4 {
+			h.Clock.FireNext(t)
+			question = expectQuestion(t, alice)[0]
+		}
+	}
+
+	summary := alice.Expect(gametest.IsSummary).Summary
+	if summary.Winner != "alice" {
+		t.Errorf("Got winner %s and expected alice", summary.Winner)
+	}
+}
+
+func TestGame_OnlyDeclaredMessageKindsAreSent(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	// Alice's client doesn't show the scores, so it never asks for round summaries. Bob's handles everything.
+	alice := h.Dial("alice")
+	hello := alice.Hello(model.KindWelcome, model.KindAboutToStart, model.KindPresentQuestion, model.KindPlayerResult)
+	if hello.Version != model.ProtocolVersion {
+		t.Errorf("Got version %d and expected %d", hello.Version, model.ProtocolVersion)
+	}
+	alice.Send(model.MessageFromPlayer{PlayerDetailsResp: &model.PlayerDetails{Name: "alice"}})
+	alice.Expect(gametest.IsWelcome)
+	bob := h.Join("bob")
+	bob.Expect(gametest.IsRoundSummary)
+
+	question := startGame(t, h, alice, bob)[0]
+	alice.Answer(gametest.CorrectOption(question))
+	alice.Expect(gametest.IsPlayerResult)
+	bob.Answer(gametest.CorrectOption(question))
+	bob.Expect(gametest.IsPlayerResult)
+	bob.Expect(gametest.IsRoundSummary)
+
+	h.Clock.FireNext(t)
+	expectQuestion(t, alice, bob)
+}
+
+func TestGame_ClientWithoutHelloIsRejected(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	alice := h.JoinAll("alice")[0]
+	old := h.Dial("old")
+	old.Send(model.MessageFromPlayer{PlayerDetailsResp: &model.PlayerDetails{Name: "old"}})
+	old.Expect(gametest.IsErrorCode(model.ErrorUnsupportedVersion))
+	old.ExpectCloseCode(websocket.CloseProtocolError)
+
+	// The old client never joined, so alice can start on her own
+	startGame(t, h, alice)
+}
+
+func TestGame_PlayerDetailsAreChecked(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	h.JoinAll("alice")
+	fp := h.Connect("newcomer")
+
+	rejected := []struct {
+		details model.PlayerDetails
+		code    model.ErrorCode
+	}{
+		{model.PlayerDetails{Name: "   "}, model.ErrorInvalidName},
+		{model.PlayerDetails{Name: "sh1t"}, model.ErrorInvalidName},
+		{model.PlayerDetails{Name: "a name far too long to fit"}, model.ErrorInvalidName},
+		{model.PlayerDetails{Name: "mallory", Icon: `x" onerror="alert(1)`}, model.ErrorInvalidIcon},
+	}
+	for _, test := range rejected {
+		details := test.details
+		fp.Send(model.MessageFromPlayer{PlayerDetailsResp: &details})
+		fp.Expect(gametest.IsErrorCode(test.code))
+	}
+
+	// The player can try again, and gets a name and icon nobody else has
+	fp.Send(model.MessageFromPlayer{PlayerDetailsResp: &model.PlayerDetails{Name: "  ALICE "}})
+	welcome := fp.Expect(gametest.IsWelcome).Welcome
+	if welcome.Name != "ALICE 2" || welcome.Icon != "Horse2" {
+		t.Errorf("Got %+v and expected ALICE 2 with the first unused horse", welcome)
+	}
+}
+
+func TestGame_AccountIdentifiesPlayer(t *testing.T) {
+	words := gametest.Words(5, "noun")
+	h := gametest.NewHarness(t, game.NewGame(words.GroupByType(), 100, 3, 10*time.Second, 7))
+	defer h.Close()
+
+	// Karl leaves the details blank, so the account's are used. A guest called Karl gets a number after the name.
+	karl := h.ConnectAs("karl", model.PlayerDetails{Name: "Karl", Icon: "Horse3"})
+	karl.Send(model.MessageFromPlayer{PlayerDetailsResp: &model.PlayerDetails{}})
+	karl.Expect(gametest.IsWelcome)
+	karl.Expect(gametest.IsRoundSummary)
+	guest := h.Join("Karl")
+	karl.Expect(gametest.IsRoundSummary)
+
+	states := guest.Expect(gametest.IsRoundSummary).RoundSummary.PlayerStates
+	expected := []model.PlayerState{
+		{Name: "Karl", Icon: "Horse3", Active: true, Account: "karl"},
+		{Name: "Karl 2", Icon: "Horse1", Active: true},
+	}
+	for i := range expected {
+		if states[i] != expected[i] {
+			t.Errorf("Got %+v and expected %+v", states[i], expected[i])
+		}
+	}
+
+	question := startGame(t, h, karl, guest)[0]
+	karl.Answer(gametest.CorrectOption(question))
+	karl.Expect(gametest.IsPlayerResult)
+	guest.Answer(gametest.WrongOption(question))
+	guest.Expect(gametest.IsPlayerResult)
+	guest.Expect(gametest.IsRoundSummary)
+
+	summary := guest.Expect(gametest.IsSummary).Summary
+	if summary.Winner != "Karl" || summary.Account != "karl" {
+		t.Errorf("Got %+v and expected the karl account to win", summary)
+	}
+}
+
+func TestGame_OnlyHostCanStart(t *testing.T) {
+	words := gametest.Words(5, "noun")
+	h := gametest.NewHarness(t, game.NewGame(words.GroupByType(), 100, 3, 10*time.Second, 7))
+	defer h.Close()
+
+	// The first player to join hosts the room
+	amy := h.Connect("Amy")
+	amy.Send(model.MessageFromPlayer{PlayerDetailsResp: &model.PlayerDetails{Name: "Amy"}})
+	amyToken := amy.Expect(gametest.IsWelcome).Welcome.HostToken
+	amy.Expect(gametest.IsRoundSummary)
+	bob := h.Connect("Bob")
+	bob.Send(model.MessageFromPlayer{PlayerDetailsResp: &model.PlayerDetails{Name: "Bob"}})
+	if token := bob.Expect(gametest.IsWelcome).Welcome.HostToken; amyToken == "" || token != "" {
+		t.Fatalf("Got host tokens %q and %q and expected only Amy to have one", amyToken, token)
+	}
+	bob.Expect(gametest.IsRoundSummary)
+
+	if err := h.Game.Start("guess"); err != game.ErrNotHost {
+		t.Errorf("Got %v and expected %v", err, game.ErrNotHost)
+	}
+
+	// Bob takes over when Amy leaves, and Amy's token stops working
+	amy.Disconnect()
+	bobToken := bob.ExpectEventually(gametest.IsHost).Host.Token
+	if err := h.Game.Start(amyToken); err != game.ErrNotHost {
+		t.Errorf("Got %v and expected %v", err, game.ErrNotHost)
+	}
+	if err := h.Game.Start(bobToken); err != nil {
+		t.Errorf("Got %v and expected the host to start the game", err)
+	}
+	bob.ExpectEventually(gametest.IsAboutToStart)
+}
+
+func TestGame_StartAsAdmin(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	if err := h.Game.StartAsAdmin(); err != game.ErrNoPlayers {
+		t.Errorf("Got %v and expected %v", err, game.ErrNoPlayers)
+	}
+
+	alice := h.JoinAll("alice")[0]
+	if err := h.Game.StartAsAdmin(); err != nil {
+		t.Errorf("Got %v and expected the game to start", err)
+	}
+	alice.Expect(gametest.IsAboutToStart)
+	if err := h.Game.StartAsAdmin(); err != game.ErrAlreadyStarted {
+		t.Errorf("Got %v and expected %v", err, game.ErrAlreadyStarted)
+	}
+}
+
+func TestGame_NoUsableWordTypes(t *testing.T) {
+	words := gametest.Words(2, "noun", "verb")
+	h := gametest.NewHarness(t, game.NewGame(words.GroupByType(), 500, 3, 10*time.Second, 7))
+	defer h.Close()
+
+	alice := h.JoinAll("alice")[0]
+	h.Start()
+	alice.Expect(gametest.IsAboutToStart)
+	h.Clock.FireNext(t)
+
+	alice.Expect(gametest.IsErrorCode(model.ErrorNoWords))
+	alice.Expect(gametest.IsSummary)
+}
+
+// waitForShutdown fails the test if the game hasn't shut down in time
+func waitForShutdown(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(gametest.MessageTimeout):
+		t.Fatal("Timed out waiting for the game to shut down")
+	}
+}
+
+func TestGame_ShutdownInLobby(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	alice := h.JoinAll("alice")[0]
+	done := h.Game.Shutdown(time.Minute)
+
+	alice.Expect(gametest.IsErrorCode(model.ErrorShuttingDown))
+	alice.ExpectCloseCode(websocket.CloseGoingAway)
+	waitForShutdown(t, done)
+
+	// Nobody else can join
+	bob := h.Connect("bob")
+	bob.Send(model.MessageFromPlayer{
+		PlayerDetailsResp: &model.PlayerDetails{Name: "bob"},
+	})
+	bob.Expect(gametest.IsErrorCode(model.ErrorShuttingDown))
+}
+
+func TestGame_ShutdownLetsGameFinish(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	alice := h.JoinAll("alice")[0]
+	question := startGame(t, h, alice)[0]
+	done := h.Game.Shutdown(time.Minute)
+
+	for round := 1; round <= 4; round++ {
+		alice.Answer(gametest.CorrectOption(question))
+		alice.Expect(gametest.IsPlayerResult)
+		alice.Expect(gametest.IsRoundSummary)
+		if round < 4 {
+			h.Clock.FireNext(t)
+			question = expectQuestion(t, alice)[0]
+		}
+	}
+
+	alice.Expect(gametest.IsSummary)
+	alice.ExpectCloseCode(websocket.CloseGoingAway)
+	waitForShutdown(t, done)
+}
+
+func TestGame_ShutdownEndsGameAfterGracePeriod(t *testing.T) {
+	h := gametest.NewHarness(t, newTestGame())
+	defer h.Close()
+
+	players := h.JoinAll("alice", "bob")
+	questions := startGame(t, h, players...)
+	done := h.Game.Shutdown(5 * time.Second)
+
+	players[0].Answer(gametest.CorrectOption(questions[0]))
+	players[0].Expect(gametest.IsPlayerResult)
+
+	// The grace period is shorter than the time left for the question, so it runs out first
+	for h.Clock.Pending() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if waited := h.Clock.FireNext(t); waited != 5*time.Second {
+		t.Errorf("Waited %s and expected the 5s grace period", waited)
+	}
+
+	for _, fp := range players {
+		fp.Expect(gametest.IsErrorCode(model.ErrorShuttingDown))
+		summary := fp.Expect(gametest.IsSummary).Summary
+		if summary.Winner != "alice" {
+			t.Errorf("Got winner %s and expected alice", summary.Winner)
+		}
+		fp.ExpectCloseCode(websocket.CloseGoingAway)
+	}
+	waitForShutdown(t, done)
+}
+
+func TestGame_UpdateWords(t *testing.T) {
+	// Too few words to ask a question
+	words := gametest.Words(2, "noun")
+	h := gametest.NewHarness(t, game.NewGame(words.GroupByType(), 500, 3, 10*time.Second, 7))
+	defer h.Close()
+
+	h.Game.UpdateWords(gametest.Words(5, "verb").GroupByType())
+
+	alice := h.JoinAll("alice")[0]
+	question := startGame(t, h, alice)[0]
+	if !strings.HasPrefix(question.WordToGuess, "verb") {
+		t.Errorf("Got word %s and expected one of the new verbs", question.WordToGuess)
+	}
+}
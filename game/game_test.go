@@ -1,23 +1,18 @@
 package game
 
 import (
-	"github.com/ksanta/wordofthedaygame/model"
 	"testing"
 	"time"
 )
 
-var words = model.Words{
-	{Word: "hello"},
-	{Word: "greetings"},
-	{Word: "hej"},
-}
-
 func TestGame_CalculatePoints(t *testing.T) {
 	g := Game{
 		WordsByType:         nil,
 		TargetScore:         500,
 		OptionsPerQuestion:  3,
 		DurationPerQuestion: 10 * time.Second,
+		CorrectPoints:       100,
+		SpeedPoints:         50,
 	}
 
 	gotPoints := g.calculatePoints(true, 2*time.Second)
@@ -31,54 +26,12 @@ func TestGame_CalculatePoints(t *testing.T) {
 	if gotPoints != expectedPoints {
 		t.Errorf("Got %d points but expected %d", gotPoints, expectedPoints)
 	}
-}
-
-func TestGame_ValidateResponse(t *testing.T) {
-	g := Game{
-		WordsByType:         nil,
-		TargetScore:         500,
-		OptionsPerQuestion:  3,
-		DurationPerQuestion: 10 * time.Second,
-		wordsInRound:        words,
-	}
-
-	// Validate responses
-	g.wordToGuess = "greetings"
-	if g.validateResponse("1") {
-		t.Error("Happy case 1 fail")
-	}
-
-	if !g.validateResponse("2") {
-		t.Error("Happy case 2 fail")
-	}
 
-	if !g.validateResponse("02") {
-		t.Error("Happy case 02 fail")
-	}
-
-	if !g.validateResponse(" 2") {
-		t.Error("Happy case ' 2' fail")
-	}
-
-	if !g.validateResponse("2 ") {
-		t.Error("Happy case '2 ' fail")
-	}
-
-	// Invalid responses
-	g.wordToGuess = "hello"
-	if g.validateResponse("0") {
-		t.Error("Zero response fail")
-	}
-
-	if g.validateResponse("-1") {
-		t.Error("Negative response fail")
-	}
-
-	if g.validateResponse("") {
-		t.Error("Blank response fail")
-	}
-
-	if g.validateResponse("A") {
-		t.Error("Alpha response fail")
+	g.CorrectPoints = 10
+	g.SpeedPoints = 200
+	gotPoints = g.calculatePoints(true, 5*time.Second)
+	expectedPoints = 10 + 100
+	if gotPoints != expectedPoints {
+		t.Errorf("Got %d points but expected %d", gotPoints, expectedPoints)
 	}
 }
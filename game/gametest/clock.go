@@ -0,0 +1,132 @@
+package gametest
+
+import (
+	"github.com/ksanta/wordofthedaygame/game"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// FakeClock is a game.Clock that only moves when it is told to
+type FakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	f        func()
+}
+
+// NewFakeClock creates a fake clock, starting at an arbitrary time
+func NewFakeClock() *FakeClock {
+	return &FakeClock{
+		now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) game.Timer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	timer := &fakeTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		f:        f,
+	}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	return t.clock.remove(t)
+}
+
+// remove takes the timer out of the pending timers. The lock must be held.
+func (c *FakeClock) remove(timer *fakeTimer) bool {
+	for i, pending := range c.timers {
+		if pending == timer {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock forward, firing the timers that come due on the way, in order. Each timer's function
+// is called on the caller's goroutine, so Advance returns once they have all run.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	end := c.now.Add(d)
+	c.lock.Unlock()
+
+	for {
+		c.lock.Lock()
+		next := c.nextTimer()
+		if next == nil || next.deadline.After(end) {
+			c.now = end
+			c.lock.Unlock()
+			return
+		}
+		c.now = next.deadline
+		c.remove(next)
+		c.lock.Unlock()
+
+		next.f()
+	}
+}
+
+// FireNext waits for a timer to be set, then moves the clock forward to it and fires it. It returns how far
+// the clock moved. It fails the test if no timer is set within a few seconds.
+func (c *FakeClock) FireNext(t *testing.T) time.Duration {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.lock.Lock()
+		next := c.nextTimer()
+		var wait time.Duration
+		if next != nil {
+			wait = next.deadline.Sub(c.now)
+		}
+		c.lock.Unlock()
+
+		if next != nil {
+			c.Advance(wait)
+			return wait
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the game to set a timer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Pending returns how many timers are waiting to fire
+func (c *FakeClock) Pending() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.timers)
+}
+
+// nextTimer returns the timer that is due first. The lock must be held.
+func (c *FakeClock) nextTimer() *fakeTimer {
+	if len(c.timers) == 0 {
+		return nil
+	}
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].deadline.Before(c.timers[j].deadline)
+	})
+	return c.timers[0]
+}
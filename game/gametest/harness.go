@@ -0,0 +1,308 @@
+// Package gametest plays games without a real server or real time passing. A Harness runs a game.Game behind
+// an httptest server, and FakePlayers connect to it over websockets and speak the model message protocol.
+package gametest
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/ksanta/wordofthedaygame/codec"
+	"github.com/ksanta/wordofthedaygame/game"
+	"github.com/ksanta/wordofthedaygame/model"
+	"github.com/ksanta/wordofthedaygame/player"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// MessageTimeout is how long a FakePlayer waits for a message before failing the test
+var MessageTimeout = 5 * time.Second
+
+// AllKinds are the kinds of message the server sends. Players connected by the harness say they handle them all.
+var AllKinds = []string{model.KindHello, model.KindPlayerDetailsReq, model.KindWelcome, model.KindHost,
+	model.KindAboutToStart, model.KindPresentQuestion, model.KindPlayerResult, model.KindRoundSummary,
+	model.KindSummary, model.KindError}
+
+// Harness runs a game that fake players can connect to
+type Harness struct {
+	t      *testing.T
+	Game   *game.Game
+	Clock  *FakeClock
+	server *httptest.Server
+}
+
+// NewHarness starts the game with a fake clock, and a server for the players to connect to. Call Close when
+// the test is done.
+func NewHarness(t *testing.T, g *game.Game) *Harness {
+	t.Helper()
+
+	clock := NewFakeClock()
+	g.Clock = clock
+	go g.Run()
+
+	upgrader := websocket.Upgrader{Subprotocols: codec.Subprotocols()}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error("upgrade fail:", err)
+			return
+		}
+		defer conn.Close()
+
+		disconnectChan := make(chan struct{})
+		p := player.NewPlayer(conn, disconnectChan, g.MessageChan, player.DefaultConnectionSettings())
+		// The query stands in for the session cookie the real server checks
+		if query := r.URL.Query(); query.Get("account") != "" {
+			p.Account = query.Get("account")
+			p.AccountDetails = model.PlayerDetails{Name: query.Get("name"), Icon: query.Get("icon")}
+		}
+		go p.ReadPump()
+		go p.WritePump()
+
+		<-disconnectChan
+	}))
+
+	return &Harness{
+		t:      t,
+		Game:   g,
+		Clock:  clock,
+		server: server,
+	}
+}
+
+// Close shuts down the server
+func (h *Harness) Close() {
+	h.server.Close()
+}
+
+// URL returns the websocket URL that players connect to
+func (h *Harness) URL() string {
+	return "ws" + strings.TrimPrefix(h.server.URL, "http")
+}
+
+// Connect opens a websocket to the game and says hello, handling every kind of message, without joining it
+func (h *Harness) Connect(name string) *FakePlayer {
+	h.t.Helper()
+	fp := h.Dial(name)
+	fp.Hello(AllKinds...)
+	return fp
+}
+
+// ConnectAs is like Connect, but the player is logged in to an account that prefers the given details
+func (h *Harness) ConnectAs(username string, details model.PlayerDetails) *FakePlayer {
+	h.t.Helper()
+	query := url.Values{"account": {username}, "name": {details.Name}, "icon": {details.Icon}}
+	fp := h.dial(username, h.URL()+"?"+query.Encode())
+	fp.Hello(AllKinds...)
+	return fp
+}
+
+// Dial opens a websocket to the game without saying hello
+func (h *Harness) Dial(name string) *FakePlayer {
+	h.t.Helper()
+	return h.dial(name, h.URL())
+}
+
+func (h *Harness) dial(name string, url string) *FakePlayer {
+	h.t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		h.t.Fatal("dial error:", err)
+	}
+
+	fp := &FakePlayer{
+		t:        h.t,
+		Name:     name,
+		conn:     conn,
+		Messages: make(chan model.MessageToPlayer, 1000),
+		closed:   make(chan struct{}),
+		stalled:  make(chan struct{}),
+	}
+	go fp.readLoop()
+	return fp
+}
+
+// Join connects a player, sends their details and waits to be welcomed
+func (h *Harness) Join(name string) *FakePlayer {
+	h.t.Helper()
+
+	fp := h.Connect(name)
+	fp.Send(model.MessageFromPlayer{
+		PlayerDetailsResp: &model.PlayerDetails{Name: name, Icon: "Horse1"},
+	})
+	fp.Expect(IsWelcome)
+	return fp
+}
+
+// JoinAll joins each of the named players. Every player sees a RoundSummary each time someone joins, and
+// these are all read, so the players are ready for the game to start.
+func (h *Harness) JoinAll(names ...string) []*FakePlayer {
+	h.t.Helper()
+
+	players := make([]*FakePlayer, 0, len(names))
+	for _, name := range names {
+		fp := h.Join(name)
+		players = append(players, fp)
+		for _, other := range players {
+			other.Expect(IsRoundSummary)
+		}
+	}
+	return players
+}
+
+// Start starts the game, the way the /start endpoint does for an admin
+func (h *Harness) Start() {
+	h.Game.StartChan <- struct{}{}
+}
+
+// FakePlayer is a client connected to the game
+type FakePlayer struct {
+	t    *testing.T
+	Name string
+	conn *websocket.Conn
+	// Messages receives every message the game sends to this player
+	Messages chan model.MessageToPlayer
+	// closed is closed when the connection is lost, and closeErr says why
+	closed   chan struct{}
+	closeErr error
+	// stalled is closed when the player stops reading from the connection
+	stalled chan struct{}
+	// questionID is the ID of the last question the test read
+	questionID int
+}
+
+func (fp *FakePlayer) readLoop() {
+	for {
+		select {
+		case <-fp.stalled:
+			// Stop reading, but leave the connection open
+			return
+		default:
+		}
+
+		var message model.MessageToPlayer
+		err := fp.conn.ReadJSON(&message)
+		if err != nil {
+			fp.closeErr = err
+			close(fp.closed)
+			return
+		}
+		fp.Messages <- message
+	}
+}
+
+// Stall stops the player reading from the connection, like a client that has hung. The connection stays open.
+func (fp *FakePlayer) Stall() {
+	close(fp.stalled)
+}
+
+// Send sends a message to the game
+func (fp *FakePlayer) Send(message model.MessageFromPlayer) {
+	fp.t.Helper()
+	err := fp.conn.WriteJSON(message)
+	if err != nil {
+		fp.t.Fatalf("%s: send error: %v", fp.Name, err)
+	}
+}
+
+// Hello tells the game which kinds of message the player handles, and waits for the game's hello back
+func (fp *FakePlayer) Hello(capabilities ...string) *model.Hello {
+	fp.t.Helper()
+	fp.Send(model.MessageFromPlayer{
+		Hello: &model.Hello{Version: model.ProtocolVersion, Capabilities: capabilities},
+	})
+	return fp.Expect(IsHello).Hello
+}
+
+// Answer responds to the last question the test read with the given option
+func (fp *FakePlayer) Answer(option int) {
+	fp.t.Helper()
+	fp.AnswerQuestion(fp.questionID, option)
+}
+
+// AnswerQuestion responds to the question with the given ID, whether or not it is still open
+func (fp *FakePlayer) AnswerQuestion(questionID int, option int) {
+	fp.t.Helper()
+	fp.Send(model.MessageFromPlayer{
+		PlayerResponse: &model.PlayerResponse{QuestionID: questionID, Response: option},
+	})
+}
+
+// Disconnect closes the player's connection without saying goodbye, like a browser tab being closed
+func (fp *FakePlayer) Disconnect() {
+	fp.conn.Close()
+}
+
+// Next returns the next message sent to the player
+func (fp *FakePlayer) Next() model.MessageToPlayer {
+	fp.t.Helper()
+	select {
+	case message := <-fp.Messages:
+		if message.PresentQuestion != nil {
+			fp.questionID = message.PresentQuestion.QuestionID
+		}
+		return message
+	case <-time.After(MessageTimeout):
+		fp.t.Fatalf("%s: timed out waiting for a message", fp.Name)
+		return model.MessageToPlayer{}
+	}
+}
+
+// Expect returns the next message, failing the test if it doesn't match
+func (fp *FakePlayer) Expect(matches func(model.MessageToPlayer) bool) model.MessageToPlayer {
+	fp.t.Helper()
+	message := fp.Next()
+	if !matches(message) {
+		fp.t.Fatalf("%s: got unexpected message %+v", fp.Name, message)
+	}
+	return message
+}
+
+// ExpectEventually skips messages until one matches
+func (fp *FakePlayer) ExpectEventually(matches func(model.MessageToPlayer) bool) model.MessageToPlayer {
+	fp.t.Helper()
+	for {
+		message := fp.Next()
+		if matches(message) {
+			return message
+		}
+	}
+}
+
+// ExpectClosed waits for the game to close the connection
+func (fp *FakePlayer) ExpectClosed() {
+	fp.t.Helper()
+	select {
+	case <-fp.closed:
+	case <-time.After(MessageTimeout):
+		fp.t.Fatalf("%s: timed out waiting for the connection to close", fp.Name)
+	}
+}
+
+// ExpectCloseCode waits for the game to close the connection with the given websocket close code
+func (fp *FakePlayer) ExpectCloseCode(code int) {
+	fp.t.Helper()
+	fp.ExpectClosed()
+	if !websocket.IsCloseError(fp.closeErr, code) {
+		fp.t.Errorf("%s: got %v and expected close code %d", fp.Name, fp.closeErr, code)
+	}
+}
+
+// Matchers for Expect
+
+func IsHello(m model.MessageToPlayer) bool           { return m.Hello != nil }
+func IsWelcome(m model.MessageToPlayer) bool         { return m.Welcome != nil }
+func IsHost(m model.MessageToPlayer) bool            { return m.Host != nil }
+func IsAboutToStart(m model.MessageToPlayer) bool    { return m.AboutToStart != nil }
+func IsPresentQuestion(m model.MessageToPlayer) bool { return m.PresentQuestion != nil }
+func IsPlayerResult(m model.MessageToPlayer) bool    { return m.PlayerResult != nil }
+func IsRoundSummary(m model.MessageToPlayer) bool    { return m.RoundSummary != nil }
+func IsSummary(m model.MessageToPlayer) bool         { return m.Summary != nil }
+func IsError(m model.MessageToPlayer) bool           { return m.Error != nil }
+
+// IsErrorCode matches an Error with the given code
+func IsErrorCode(code model.ErrorCode) func(model.MessageToPlayer) bool {
+	return func(m model.MessageToPlayer) bool { return m.Error != nil && m.Error.Code == code }
+}
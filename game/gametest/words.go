@@ -0,0 +1,42 @@
+package gametest
+
+import (
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/model"
+	"strings"
+)
+
+// definitionPrefix starts every definition made by Words, so the answer can be found from the definition
+const definitionPrefix = "definition of "
+
+// Words makes perType words of each word type. Each definition names its word, so CorrectOption can answer
+// any question.
+func Words(perType int, wordTypes ...string) model.Words {
+	var words model.Words
+	for _, wordType := range wordTypes {
+		for i := 0; i < perType; i++ {
+			word := fmt.Sprintf("%s%d", strings.ReplaceAll(wordType, " ", "-"), i)
+			words = append(words, model.Word{
+				Word:       word,
+				WordType:   wordType,
+				Definition: definitionPrefix + word,
+			})
+		}
+	}
+	return words
+}
+
+// CorrectOption returns the index of the correct definition for a question asked using Words
+func CorrectOption(question *model.PresentQuestion) int {
+	for i, definition := range question.Definitions {
+		if definition == definitionPrefix+question.WordToGuess {
+			return i
+		}
+	}
+	return -1
+}
+
+// WrongOption returns the index of an incorrect definition for a question asked using Words
+func WrongOption(question *model.PresentQuestion) int {
+	return (CorrectOption(question) + 1) % len(question.Definitions)
+}
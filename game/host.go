@@ -0,0 +1,81 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"github.com/ksanta/wordofthedaygame/model"
+	"github.com/ksanta/wordofthedaygame/player"
+	"log"
+)
+
+var (
+	// ErrNotHost is returned when starting the game with a token that isn't the host's
+	ErrNotHost = errors.New("only the room's host can start the game")
+	// ErrAlreadyStarted is returned when starting a game that isn't waiting in the lobby
+	ErrAlreadyStarted = errors.New("the game has already started")
+	// ErrNoPlayers is returned when starting a game nobody has joined
+	ErrNoPlayers = errors.New("nobody has joined the game")
+)
+
+// hostTokenBytes is how much randomness is in a host token, enough that it can't be guessed
+const hostTokenBytes = 16
+
+// startRequest asks the game to start on behalf of whoever has the token, or of an admin
+type startRequest struct {
+	token string
+	admin bool
+	reply chan error
+}
+
+// Start starts the game if the token is the host's. The host is the first player to join, and their Welcome
+// carries the token. Run must be running.
+func (game *Game) Start(token string) error {
+	reply := make(chan error, 1)
+	game.startRequests <- startRequest{token: token, reply: reply}
+	return <-reply
+}
+
+// StartAsAdmin starts the game for whoever asks. The caller must have checked they are an admin. Run must be
+// running.
+func (game *Game) StartAsAdmin() error {
+	reply := make(chan error, 1)
+	game.startRequests <- startRequest{admin: true, reply: reply}
+	return <-reply
+}
+
+func (game *Game) handleStartRequest(request startRequest) error {
+	if !request.admin &&
+		(game.host == nil || subtle.ConstantTimeCompare([]byte(request.token), []byte(game.hostToken)) != 1) {
+		return ErrNotHost
+	}
+	return game.start()
+}
+
+// makeHost lets the player start the game. Each host gets a new token, so an earlier host's stops working.
+func (game *Game) makeHost(p *player.Player) {
+	random := make([]byte, hostTokenBytes)
+	_, err := rand.Read(random)
+	if err != nil {
+		// Without a token nobody can start the game as host, but an admin still can
+		log.Println("Unable to make a host token:", err)
+		return
+	}
+	game.host = p
+	game.hostToken = base64.RawURLEncoding.EncodeToString(random)
+}
+
+// handOverHost passes hosting to the next active player when the host leaves
+func (game *Game) handOverHost() {
+	game.host = nil
+	game.hostToken = ""
+	for _, p := range game.players {
+		if p.Active {
+			game.makeHost(p)
+			p.Send(model.MessageToPlayer{Host: &model.Host{Token: game.hostToken}})
+			log.Println(p.GetName(), "is now hosting")
+			return
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Answer results, used to label the answers counter
+const (
+	answerCorrect = "correct"
+	answerWrong   = "wrong"
+	answerTimeout = "timeout"
+)
+
+var (
+	activeGames = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "active_games",
+		Help:      "Number of games between starting and finishing.",
+	})
+	gamesStarted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "games_started_total",
+		Help:      "Number of games started.",
+	})
+	gamesFinished = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "games_finished_total",
+		Help:      "Number of games played until the end.",
+	})
+	answers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "answers_total",
+		Help:      "Number of questions answered, by result: correct, wrong or timeout.",
+	}, []string{"result"})
+	answerLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "answer_latency_seconds",
+		Help:      "Time players take to answer a question.",
+		Buckets:   []float64{0.5, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	})
+)
+
+// isActive is true for the phases a game passes through while it is being played
+func (phase Phase) isActive() bool {
+	return phase == Countdown || phase == Question || phase == Reveal
+}
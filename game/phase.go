@@ -0,0 +1,38 @@
+package game
+
+// Phase is the stage that a game is at. A game moves through the phases in order:
+//
+//	Lobby -> Countdown -> Question -> Reveal -> Question -> ... -> Finished -> Lobby
+//
+// If every player leaves, the game goes straight back to the Lobby.
+type Phase int
+
+const (
+	// Lobby is waiting for players to join and for the game to be started
+	Lobby Phase = iota
+	// Countdown has told the players the game is about to begin
+	Countdown
+	// Question has sent a question to the players and is collecting their answers
+	Question
+	// Reveal shows the players the scores before the next question
+	Reveal
+	// Finished has told the players who won
+	Finished
+)
+
+func (phase Phase) String() string {
+	switch phase {
+	case Lobby:
+		return "Lobby"
+	case Countdown:
+		return "Countdown"
+	case Question:
+		return "Question"
+	case Reveal:
+		return "Reveal"
+	case Finished:
+		return "Finished"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+)
+
+// watchBufferSize is how many messages a watcher can fall behind before it is dropped
+const watchBufferSize = 64
+
+// State is a read-only view of the game, for dashboards and other consumers that don't play
+type State struct {
+	Phase       string
+	TargetScore int
+	Players     []model.PlayerState
+	// QuestionID, Word and Definitions are the current question, as the players see it
+	QuestionID  int      `json:",omitempty"`
+	Word        string   `json:",omitempty"`
+	Definitions []string `json:",omitempty"`
+	// Answer is the correct definition. It is hidden until the answer is revealed.
+	Answer string `json:",omitempty"`
+}
+
+// State returns the current state of the game. Run must be running.
+func (game *Game) State() State {
+	reply := make(chan State, 1)
+	game.stateChan <- reply
+	return <-reply
+}
+
+// Watch returns a channel that receives a copy of every message broadcast to all the players. A watcher that
+// falls too far behind is dropped and its channel closed, as it is when the game shuts down. Call stop when done
+// watching. Run must be running.
+func (game *Game) Watch() (messages <-chan model.MessageToPlayer, stop func()) {
+	watcher := make(chan model.MessageToPlayer, watchBufferSize)
+	game.watchChan <- watcher
+	stop = func() {
+		game.unwatchChan <- watcher
+	}
+	return watcher, stop
+}
+
+func (game *Game) state() State {
+	state := State{
+		Phase:       game.phase.String(),
+		TargetScore: game.TargetScore,
+		Players:     game.playerStates(),
+	}
+	if game.question != nil {
+		state.QuestionID = game.question.QuestionID
+		state.Word = game.question.WordToGuess
+		state.Definitions = game.question.Definitions
+		if game.phase == Reveal {
+			state.Answer = game.question.Definitions[game.correctAnswer]
+		}
+	}
+	return state
+}
+
+// notifyWatchers sends a copy of a broadcast message to every watcher. Watchers that are full are dropped,
+// so they can't hold up the game.
+func (game *Game) notifyWatchers(message model.MessageToPlayer) {
+	for watcher := range game.watchers {
+		select {
+		case watcher <- message:
+		default:
+			game.unwatch(watcher)
+		}
+	}
+}
+
+func (game *Game) unwatch(watcher chan model.MessageToPlayer) {
+	if game.watchers[watcher] {
+		delete(game.watchers, watcher)
+		close(watcher)
+	}
+}
+
+// unwatchAll lets every watcher go, eg when the game shuts down
+func (game *Game) unwatchAll() {
+	for watcher := range game.watchers {
+		game.unwatch(watcher)
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/ksanta/wordofthedaygame
 
-go 1.13
+go 1.16
 
 require (
 	github.com/PuerkitoBio/goquery v1.5.1 // indirect
@@ -11,12 +11,16 @@ require (
 	github.com/gobwas/glob v0.2.3 // indirect
 	github.com/gocolly/colly v1.2.0
 	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
-	github.com/golang/protobuf v1.3.3 // indirect
 	github.com/gorilla/websocket v1.4.2
 	github.com/kennygrant/sanitize v1.2.4 // indirect
+	github.com/pelletier/go-toml v1.9.5
+	github.com/prometheus/client_golang v1.12.2
 	github.com/saintfish/chardet v0.0.0-20120816061221-3af4cd4741ca // indirect
 	github.com/stretchr/testify v1.4.0 // indirect
 	github.com/temoto/robotstxt v1.1.1 // indirect
-	golang.org/x/net v0.0.0-20200219183655-46282727080f // indirect
-	google.golang.org/appengine v1.6.5 // indirect
+	github.com/vmihailenco/msgpack/v4 v4.3.13
+	golang.org/x/crypto v0.23.0
+	golang.org/x/image v0.18.0
+	golang.org/x/time v0.5.0
+	gopkg.in/yaml.v2 v2.4.0
 )
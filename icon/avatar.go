@@ -0,0 +1,177 @@
+package icon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/model"
+	"golang.org/x/image/draw"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	// Avatars can be uploaded in any of these formats
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// AvatarSize is the width and height of avatars, in pixels. It matches the built-in icons.
+const AvatarSize = 100
+
+// maxSourceSize is the widest or tallest image that can be uploaded. Bigger images would take too much memory
+// to decode, even if they compress down small. Uploads are decoded one at a time, so only one image this big is
+// in memory at once.
+const maxSourceSize = 4096
+
+var (
+	// ErrNotAnImage is returned when an upload isn't an image in one of the allowed formats
+	ErrNotAnImage = errors.New("avatars must be PNG, JPEG or GIF images")
+	// ErrImageTooBig is returned when an upload is too wide or too tall
+	ErrImageTooBig = fmt.Errorf("avatars can't be bigger than %dx%d pixels", maxSourceSize, maxSourceSize)
+	// ErrAvatarsDisabled is returned when uploading to a catalogue without an avatar directory
+	ErrAvatarsDisabled = errors.New("avatars can't be uploaded")
+	// ErrTooManyAvatars is returned when uploading a new avatar to a catalogue that has MaxAvatars already
+	ErrTooManyAvatars = errors.New("no more avatars can be uploaded")
+)
+
+var avatarPattern = regexp.MustCompile(`^avatar-[0-9a-f]{16}$`)
+
+// LoadAvatars lets avatars be uploaded, saving them to the directory. Avatars already in the directory can be
+// used straight away.
+func (c *Catalogue) LoadAvatars(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.avatarDir = dir
+	for _, file := range files {
+		id := strings.TrimSuffix(file.Name(), ".png")
+		if !file.IsDir() && avatarPattern.MatchString(id) {
+			c.avatars[id] = true
+		}
+	}
+	return nil
+}
+
+// AddAvatar decodes an uploaded image, crops it square and resizes it to AvatarSize, then saves it as a PNG.
+// The ID comes from the image itself, so uploading the same image twice gives the same avatar.
+func (c *Catalogue) AddAvatar(r io.Reader) (model.Icon, error) {
+	if c.avatarDirectory() == "" {
+		return model.Icon{}, ErrAvatarsDisabled
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return model.Icon{}, err
+	}
+
+	encoded, err := c.decode(data)
+	if err != nil {
+		return model.Icon{}, err
+	}
+	hash := sha256.Sum256(encoded)
+	id := "avatar-" + hex.EncodeToString(hash[:8])
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.avatars[id] {
+		if c.MaxAvatars > 0 && len(c.avatars) >= c.MaxAvatars {
+			return model.Icon{}, ErrTooManyAvatars
+		}
+		err = os.MkdirAll(c.avatarDir, 0755)
+		if err != nil {
+			return model.Icon{}, err
+		}
+		// Write to a temporary file first, so a half-written avatar is never served
+		tempFile := c.avatarFile(id) + ".tmp"
+		err = ioutil.WriteFile(tempFile, encoded, 0644)
+		if err != nil {
+			return model.Icon{}, err
+		}
+		err = os.Rename(tempFile, c.avatarFile(id))
+		if err != nil {
+			return model.Icon{}, err
+		}
+		c.avatars[id] = true
+	}
+	return avatarIcon(id), nil
+}
+
+// decode turns an uploaded image into an avatar's PNG
+func (c *Catalogue) decode(data []byte) ([]byte, error) {
+	// Check the size before decoding the whole image
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotAnImage
+	}
+	if config.Width > maxSourceSize || config.Height > maxSourceSize {
+		return nil, ErrImageTooBig
+	}
+
+	c.decoding.Lock()
+	defer c.decoding.Unlock()
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotAnImage
+	}
+
+	var encoded bytes.Buffer
+	err = png.Encode(&encoded, resize(source))
+	if err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// AvatarFile returns the file holding the avatar's image, or false if there is no such avatar
+func (c *Catalogue) AvatarFile(id string) (string, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.avatars[id] {
+		return "", false
+	}
+	return c.avatarFile(id), true
+}
+
+func (c *Catalogue) avatarDirectory() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.avatarDir
+}
+
+func (c *Catalogue) avatarFile(id string) string {
+	return filepath.Join(c.avatarDir, id+".png")
+}
+
+func avatarIcon(id string) model.Icon {
+	return model.Icon{ID: id, URL: "/api/icons/" + id}
+}
+
+// resize crops the middle square out of the image and scales it to AvatarSize
+func resize(source image.Image) image.Image {
+	bounds := source.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	square := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	avatar := image.NewRGBA(image.Rect(0, 0, AvatarSize, AvatarSize))
+	draw.CatmullRom.Scale(avatar, avatar.Bounds(), source, square, draw.Src, nil)
+	return avatar
+}
@@ -0,0 +1,124 @@
+package icon
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testImage encodes a wide image, red on the left and right with blue in the middle
+func testImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	source := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if x < (width-height)/2 || x >= (width+height)/2 {
+				source.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				source.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, source, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	return encoded.Bytes()
+}
+
+func TestCatalogue_AddAvatar(t *testing.T) {
+	dir := t.TempDir()
+	catalogue := NewCatalogue(Horses...)
+	if err := catalogue.LoadAvatars(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	avatar, err := catalogue.AddAvatar(bytes.NewReader(testImage(t, 300, 200)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !avatarPattern.MatchString(avatar.ID) || avatar.URL != "/api/icons/"+avatar.ID {
+		t.Errorf("Got %+v and expected an avatar icon", avatar)
+	}
+	if !catalogue.Has(avatar.ID) {
+		t.Errorf("Got no avatar %s and expected the catalogue to have it", avatar.ID)
+	}
+	if len(catalogue.Icons()) != len(Horses) {
+		t.Errorf("Got %d icons and expected only the %d built-in ones", len(catalogue.Icons()), len(Horses))
+	}
+
+	// The avatar is the blue square from the middle of the image, at AvatarSize
+	file, ok := catalogue.AvatarFile(avatar.ID)
+	if !ok {
+		t.Fatal("Got no avatar file and expected one")
+	}
+	saved, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer saved.Close()
+	decoded, err := png.Decode(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := decoded.Bounds().Size(); size != image.Pt(AvatarSize, AvatarSize) {
+		t.Errorf("Got %v and expected %dx%d", size, AvatarSize, AvatarSize)
+	}
+	if r, _, b, _ := decoded.At(2, AvatarSize/2).RGBA(); r > b {
+		t.Errorf("Got red at the avatar's edge and expected the middle of the image to be kept")
+	}
+
+	// Avatars already saved are there when the server restarts
+	restarted := NewCatalogue(Horses...)
+	if err := restarted.LoadAvatars(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !restarted.Has(avatar.ID) {
+		t.Errorf("Got no avatar %s after a restart and expected it to be loaded", avatar.ID)
+	}
+}
+
+func TestCatalogue_AddAvatarRejectsBadImages(t *testing.T) {
+	catalogue := NewCatalogue(Horses...)
+
+	if _, err := catalogue.AddAvatar(bytes.NewReader(testImage(t, 20, 20))); err != ErrAvatarsDisabled {
+		t.Errorf("Got %v and expected %v", err, ErrAvatarsDisabled)
+	}
+
+	if err := catalogue.LoadAvatars(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := catalogue.AddAvatar(strings.NewReader("<svg></svg>")); err != ErrNotAnImage {
+		t.Errorf("Got %v and expected %v", err, ErrNotAnImage)
+	}
+	if _, err := catalogue.AddAvatar(bytes.NewReader(testImage(t, maxSourceSize+1, 1))); err != ErrImageTooBig {
+		t.Errorf("Got %v and expected %v", err, ErrImageTooBig)
+	}
+}
+
+func TestCatalogue_AddAvatarLimit(t *testing.T) {
+	catalogue := NewCatalogue(Horses...)
+	catalogue.MaxAvatars = 1
+	if err := catalogue.LoadAvatars(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := catalogue.AddAvatar(bytes.NewReader(testImage(t, 20, 20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := catalogue.AddAvatar(bytes.NewReader(testImage(t, 30, 20))); err != ErrTooManyAvatars {
+		t.Errorf("Got %v and expected %v", err, ErrTooManyAvatars)
+	}
+
+	// Uploading an avatar that is already saved doesn't use up any more room
+	again, err := catalogue.AddAvatar(bytes.NewReader(testImage(t, 20, 20)))
+	if err != nil || again != first {
+		t.Errorf("Got %+v, %v and expected %+v", again, err, first)
+	}
+}
@@ -0,0 +1,108 @@
+package icon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Handler serves the icon HTTP API. It expects to be mounted with the prefix stripped, eg
+// http.StripPrefix("/api/icons", handler), and serves:
+//
+//	GET  /       lists the built-in icons players can pick from
+//	POST /       uploads a PNG, JPEG or GIF image as an avatar, returning its icon
+//	GET  /{id}   returns the icon's image. Built-in icons redirect to their URL.
+type Handler struct {
+	catalogue      *Catalogue
+	maxUploadBytes int64
+}
+
+// NewHandler creates the icon HTTP API. Uploads can be up to maxUploadBytes.
+func NewHandler(catalogue *Catalogue, maxUploadBytes int64) *Handler {
+	return &Handler{
+		catalogue:      catalogue,
+		maxUploadBytes: maxUploadBytes,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(r.URL.Path, "/")
+
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, h.catalogue.Icons())
+		case http.MethodPost:
+			h.uploadAvatar(w, r)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.getImage(w, r, id)
+}
+
+func (h *Handler) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	// Read one byte more than allowed, to tell whether the upload is too big
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, h.maxUploadBytes+1))
+	if err != nil {
+		http.Error(w, "Unable to read the avatar: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if int64(len(data)) > h.maxUploadBytes {
+		http.Error(w, fmt.Sprintf("Avatars can't be bigger than %d bytes", h.maxUploadBytes),
+			http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	icon, err := h.catalogue.AddAvatar(bytes.NewReader(data))
+	switch err {
+	case nil:
+		log.Println("Saved avatar", icon.ID)
+		writeJSON(w, http.StatusCreated, icon)
+	case ErrNotAnImage, ErrImageTooBig:
+		http.Error(w, "Invalid avatar: "+err.Error(), http.StatusUnprocessableEntity)
+	case ErrAvatarsDisabled:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case ErrTooManyAvatars:
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+	default:
+		log.Println("Unable to save avatar:", err)
+		http.Error(w, fmt.Sprint("Internal error: ", err), http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) getImage(w http.ResponseWriter, r *http.Request, id string) {
+	if file, ok := h.catalogue.AvatarFile(id); ok {
+		// The ID comes from the image, so the image at a URL never changes
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		http.ServeFile(w, r, file)
+		return
+	}
+	if icon, ok := h.catalogue.Get(id); ok {
+		http.Redirect(w, r, icon.URL, http.StatusFound)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		log.Println("Unable to write JSON response:", err)
+	}
+}
@@ -0,0 +1,111 @@
+package icon
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ksanta/wordofthedaygame/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestServer(t *testing.T, maxUploadBytes int64) *httptest.Server {
+	t.Helper()
+	catalogue := NewCatalogue(Horses...)
+	if err := catalogue.LoadAvatars(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.StripPrefix("/api/icons", NewHandler(catalogue, maxUploadBytes)))
+}
+
+func TestHandler_ListIcons(t *testing.T) {
+	server := newTestServer(t, 1<<20)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/api/icons")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var icons []model.Icon
+	if err := json.NewDecoder(response.Body).Decode(&icons); err != nil {
+		t.Fatal(err)
+	}
+	if len(icons) != len(Horses) || icons[0] != Horses[0] {
+		t.Errorf("Got %+v and expected %+v", icons, Horses)
+	}
+}
+
+func TestHandler_UploadAvatar(t *testing.T) {
+	server := newTestServer(t, 1<<20)
+	defer server.Close()
+
+	response, err := http.Post(server.URL+"/api/icons", "image/jpeg", bytes.NewReader(testImage(t, 40, 40)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var avatar model.Icon
+	if err := json.NewDecoder(response.Body).Decode(&avatar); err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("Got %d and expected %d", response.StatusCode, http.StatusCreated)
+	}
+
+	image, err := http.Get(server.URL + avatar.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer image.Body.Close()
+	if image.StatusCode != http.StatusOK || image.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("Got %d %s and expected the avatar's PNG", image.StatusCode, image.Header.Get("Content-Type"))
+	}
+}
+
+func TestHandler_UploadTooBig(t *testing.T) {
+	server := newTestServer(t, 100)
+	defer server.Close()
+
+	response, err := http.Post(server.URL+"/api/icons", "image/jpeg", bytes.NewReader(testImage(t, 40, 40)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Got %d and expected %d", response.StatusCode, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestHandler_GetImage(t *testing.T) {
+	server := newTestServer(t, 1<<20)
+	defer server.Close()
+
+	// Don't follow redirects, so the redirect itself can be checked
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/api/icons/Horse3", http.StatusFound, "/images/Horse3.png"},
+		{"/api/icons/avatar-0123456789abcdef", http.StatusNotFound, ""},
+		{"/api/icons/..%2Fsecret", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		response, err := client.Get(server.URL + test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != test.status || response.Header.Get("Location") != test.location {
+			t.Errorf("%s: Got %d %q and expected %d %q", test.path, response.StatusCode,
+				response.Header.Get("Location"), test.status, test.location)
+		}
+	}
+}
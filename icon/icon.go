@@ -0,0 +1,79 @@
+// Package icon keeps the catalogue of icons players can pick from, along with the avatars players upload
+package icon
+
+import (
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/model"
+	"sync"
+)
+
+// Horses are the built-in icons
+var Horses = horses(7)
+
+func horses(count int) []model.Icon {
+	icons := make([]model.Icon, count)
+	for i := range icons {
+		id := fmt.Sprintf("Horse%d", i+1)
+		icons[i] = model.Icon{ID: id, URL: "/images/" + id + ".png"}
+	}
+	return icons
+}
+
+// Catalogue is the set of icons players can pick from. Players can also upload their own avatars, which can be
+// used by anyone who knows their ID. It is safe to use from many goroutines.
+type Catalogue struct {
+	lock    sync.RWMutex
+	icons   []model.Icon
+	builtIn map[string]model.Icon
+	// avatarDir is where uploaded avatars are saved. Avatars can't be uploaded if it is blank.
+	avatarDir string
+	avatars   map[string]bool
+	// decoding is held while an upload is decoded, so only one image at a time takes up memory
+	decoding sync.Mutex
+	// MaxAvatars is how many avatars can be saved. There is no limit if it is 0.
+	MaxAvatars int
+}
+
+// NewCatalogue creates a catalogue of the given icons, in that order
+func NewCatalogue(icons ...model.Icon) *Catalogue {
+	c := &Catalogue{
+		builtIn: make(map[string]model.Icon),
+		avatars: make(map[string]bool),
+	}
+	for _, icon := range icons {
+		if _, ok := c.builtIn[icon.ID]; !ok {
+			c.icons = append(c.icons, icon)
+			c.builtIn[icon.ID] = icon
+		}
+	}
+	return c
+}
+
+// Icons returns the built-in icons, in order. Avatars aren't included, since they belong to the players who
+// uploaded them.
+func (c *Catalogue) Icons() []model.Icon {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]model.Icon(nil), c.icons...)
+}
+
+// Has returns true if the icon is a built-in icon or an uploaded avatar
+func (c *Catalogue) Has(id string) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	_, ok := c.builtIn[id]
+	return ok || c.avatars[id]
+}
+
+// Get returns the icon with the given ID
+func (c *Catalogue) Get(id string) (model.Icon, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if icon, ok := c.builtIn[id]; ok {
+		return icon, true
+	}
+	if c.avatars[id] {
+		return avatarIcon(id), true
+	}
+	return model.Icon{}, false
+}
@@ -0,0 +1,142 @@
+// Package lobby keeps track of the rooms that games are played in. Each room runs its own game.
+package lobby
+
+import (
+	"errors"
+	"github.com/ksanta/wordofthedaygame/game"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultRoom is the room players join when they don't ask for one
+const DefaultRoom = "default"
+
+// ErrInvalidRoomName is returned when the room name isn't allowed
+var ErrInvalidRoomName = errors.New("room names must be 1-50 letters, digits, dashes or underscores")
+
+// ErrShuttingDown is returned when a room is joined after the lobby has started shutting down
+var ErrShuttingDown = errors.New("the server is shutting down")
+
+var roomNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,50}$`)
+
+// Options are the choices a room makes when it is created
+type Options struct {
+	// Decks are the decks the room's game draws its words from
+	Decks []string
+	// MixedTypes lets the options in a question have different word types
+	MixedTypes bool
+}
+
+// GameFactory creates and starts a game for the named room with the given options
+type GameFactory func(room string, options Options) (*game.Game, error)
+
+// Room is a named place where a game is played
+type Room struct {
+	Name    string
+	Options Options
+	Game    *game.Game
+}
+
+// Lobby holds all the rooms. It is safe to use from many goroutines.
+type Lobby struct {
+	lock         sync.Mutex
+	rooms        map[string]*Room
+	newGame      GameFactory
+	defaultDecks []string
+	shuttingDown bool
+}
+
+// NewLobby creates a lobby that uses newGame to start the game in each new room. Rooms that don't ask for
+// particular decks use defaultDecks.
+func NewLobby(newGame GameFactory, defaultDecks []string) *Lobby {
+	return &Lobby{
+		rooms:        make(map[string]*Room),
+		newGame:      newGame,
+		defaultDecks: defaultDecks,
+	}
+}
+
+// Join returns the named room, creating it if it doesn't exist yet. The options are only used when the room is
+// created. After that, the room keeps the options it was created with.
+func (l *Lobby) Join(name string, options Options) (*Room, error) {
+	if name == "" {
+		name = DefaultRoom
+	}
+	if !roomNamePattern.MatchString(name) {
+		return nil, ErrInvalidRoomName
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.shuttingDown {
+		return nil, ErrShuttingDown
+	}
+	if room, ok := l.rooms[name]; ok {
+		return room, nil
+	}
+
+	if len(options.Decks) == 0 {
+		options.Decks = l.defaultDecks
+	}
+	g, err := l.newGame(name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	room := &Room{
+		Name:    name,
+		Options: options,
+		Game:    g,
+	}
+	l.rooms[name] = room
+	openRooms.Inc()
+	return room, nil
+}
+
+// Get returns the named room, if it exists
+func (l *Lobby) Get(name string) (*Room, bool) {
+	if name == "" {
+		name = DefaultRoom
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	room, ok := l.rooms[name]
+	return room, ok
+}
+
+// Rooms returns all the rooms, ordered by name
+func (l *Lobby) Rooms() []*Room {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	rooms := make([]*Room, 0, len(l.rooms))
+	for _, room := range l.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].Name < rooms[j].Name
+	})
+	return rooms
+}
+
+// Shutdown stops anyone joining a room, then shuts down every room's game. Games in progress have up to grace
+// to finish. It returns once every game has closed its players.
+func (l *Lobby) Shutdown(grace time.Duration) {
+	l.lock.Lock()
+	l.shuttingDown = true
+	l.lock.Unlock()
+
+	rooms := l.Rooms()
+	done := make([]<-chan struct{}, 0, len(rooms))
+	for _, room := range rooms {
+		done = append(done, room.Game.Shutdown(grace))
+	}
+	for _, gameDone := range done {
+		<-gameDone
+	}
+}
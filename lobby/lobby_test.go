@@ -0,0 +1,96 @@
+package lobby
+
+import (
+	"github.com/ksanta/wordofthedaygame/game"
+	"testing"
+	"time"
+)
+
+// countingFactory creates games without running them, and remembers the decks each one was created with
+type countingFactory struct {
+	decks [][]string
+}
+
+func (f *countingFactory) newGame(room string, options Options) (*game.Game, error) {
+	f.decks = append(f.decks, options.Decks)
+	return game.NewGame(nil, 500, 3, 10*time.Second, 7), nil
+}
+
+func TestLobby_Join(t *testing.T) {
+	factory := &countingFactory{}
+	l := NewLobby(factory.newGame, []string{"wotd"})
+
+	first, err := l.Join("team", Options{Decks: []string{"jargon"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := l.Join("team", Options{Decks: []string{"ignored"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first != second {
+		t.Error("Expected joining the same room twice to return the same room")
+	}
+	if len(factory.decks) != 1 || factory.decks[0][0] != "jargon" {
+		t.Errorf("Expected one game created with the jargon deck, got %v", factory.decks)
+	}
+}
+
+func TestLobby_Join_DefaultRoomAndDecks(t *testing.T) {
+	factory := &countingFactory{}
+	l := NewLobby(factory.newGame, []string{"wotd"})
+
+	room, err := l.Join("", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if room.Name != DefaultRoom {
+		t.Errorf("Got room %q and expected %q", room.Name, DefaultRoom)
+	}
+	if len(room.Options.Decks) != 1 || room.Options.Decks[0] != "wotd" {
+		t.Errorf("Got decks %v and expected [wotd]", room.Options.Decks)
+	}
+
+	got, ok := l.Get("")
+	if !ok || got != room {
+		t.Error("Expected Get to find the default room")
+	}
+}
+
+func TestLobby_Join_InvalidName(t *testing.T) {
+	l := NewLobby((&countingFactory{}).newGame, nil)
+
+	_, err := l.Join("no spaces allowed", Options{})
+	if err != ErrInvalidRoomName {
+		t.Errorf("Got %v and expected %v", err, ErrInvalidRoomName)
+	}
+}
+
+func TestLobby_Shutdown(t *testing.T) {
+	factory := &countingFactory{}
+	l := NewLobby(factory.newGame, nil)
+
+	room, err := l.Join("team", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go room.Game.Run()
+
+	// Games without players shut down straight away
+	done := make(chan struct{})
+	go func() {
+		l.Shutdown(time.Minute)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the lobby to shut down")
+	}
+
+	_, err = l.Join("team", Options{})
+	if err != ErrShuttingDown {
+		t.Errorf("Got %v and expected %v", err, ErrShuttingDown)
+	}
+}
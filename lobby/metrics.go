@@ -0,0 +1,12 @@
+package lobby
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var openRooms = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: "wordofthedaygame",
+	Name:      "rooms",
+	Help:      "Number of rooms that have been created.",
+})
@@ -2,8 +2,13 @@ package model
 
 // MessageToPlayer is sent across the network to the client
 type MessageToPlayer struct {
+	// Seq numbers the messages sent on a connection, starting from 1. A gap means messages were dropped
+	// because the client couldn't keep up.
+	Seq              uint64            `json:",omitempty"`
+	Hello            *Hello            `json:",omitempty"`
 	PlayerDetailsReq *PlayerDetailsReq `json:",omitempty"`
 	Welcome          *Welcome          `json:",omitempty"`
+	Host             *Host             `json:",omitempty"`
 	AboutToStart     *AboutToStart     `json:",omitempty"`
 	PresentQuestion  *PresentQuestion  `json:",omitempty"`
 	PlayerResult     *PlayerResult     `json:",omitempty"`
@@ -14,17 +19,29 @@ type MessageToPlayer struct {
 
 // MessageFromPlayer is received from the network from the client
 type MessageFromPlayer struct {
+	Hello             *Hello          `json:",omitempty"`
 	PlayerDetailsResp *PlayerDetails  `json:",omitempty"`
 	PlayerResponse    *PlayerResponse `json:",omitempty"`
 	Disconnected      *Disconnected   `json:",omitempty"`
 }
 
-// PlayerDetailsReq is sent to the client telling it to get the player's details
-type PlayerDetailsReq struct{}
+// PlayerDetailsReq is sent to the client telling it to get the player's details. It is sent after the hello.
+type PlayerDetailsReq struct {
+	// Icons are the icons the player can pick from
+	Icons []Icon `json:",omitempty"`
+}
+
+// Icon is a picture that represents a player. PlayerState and PlayerDetails refer to icons by ID.
+type Icon struct {
+	ID string
+	// URL is where the icon's image is
+	URL string
+}
 
-// PlayerResponse is the response from the player
+// PlayerResponse is the response from the player. QuestionID must match the question being answered.
 type PlayerResponse struct {
-	Response int
+	QuestionID int
+	Response   int
 }
 
 // Disconnected is sent from the Player type to the Game when the websocket connection is lost
@@ -39,6 +56,18 @@ type PlayerDetails struct {
 // Welcome tells the client to display an intro to the player
 type Welcome struct {
 	TargetScore int
+	// Name and Icon are what the player plays as. The name may have a number added to tell them apart from
+	// another player with the same name.
+	Name string `json:",omitempty"`
+	Icon string `json:",omitempty"`
+	// HostToken is only given to the player hosting the room, who starts the game by sending it to /start
+	HostToken string `json:",omitempty"`
+}
+
+// Host tells a player they are now hosting the room, because the host left. Token is sent to /start to start
+// the game.
+type Host struct {
+	Token string
 }
 
 // AboutToStart tells all players that the game will start in X seconds
@@ -48,6 +77,8 @@ type AboutToStart struct {
 
 // PresentQuestion is sent to the client telling it to pose a question to the player
 type PresentQuestion struct {
+	// QuestionID identifies the question, so a late answer isn't mistaken for an answer to the next one
+	QuestionID     int
 	WordToGuess    string
 	Definitions    []string
 	SecondsAllowed int
@@ -55,6 +86,7 @@ type PresentQuestion struct {
 
 // PlayerResult is sent to the player telling them their result of the round
 type PlayerResult struct {
+	QuestionID    int
 	Correct       bool
 	Points        int
 	CorrectAnswer int
@@ -69,6 +101,9 @@ type PlayerState struct {
 	Icon   string
 	Score  int
 	Active bool
+	// Account is the username of the player's account, or blank for a guest. Unlike the name, it is unique
+	// and lasts between games, so it is what leaderboards and history are kept by.
+	Account string `json:",omitempty"`
 }
 
 // Summary is sent to the client at the end telling the player the final result
@@ -76,8 +111,35 @@ type Summary struct {
 	Winner      string
 	Icon        string
 	TotalPoints int
+	// Account is the username of the winner's account, or blank for a guest
+	Account string `json:",omitempty"`
 }
 
+// GameError tells the client something went wrong. Code says what, so the client can react to it, and Message
+// says it in words that can be shown to the player.
 type GameError struct {
+	Code    ErrorCode `json:",omitempty"`
 	Message string
+	// RetryAfter is the number of seconds to wait before trying again, when trying again later might work
+	RetryAfter int `json:",omitempty"`
 }
+
+// ErrorCode says what went wrong, so clients can react without reading the message
+type ErrorCode string
+
+const (
+	// ErrorGameInProgress turns away a player who joins while a game is being played. They can join the next game.
+	ErrorGameInProgress ErrorCode = "game-in-progress"
+	// ErrorShuttingDown is sent when the server is shutting down
+	ErrorShuttingDown ErrorCode = "shutting-down"
+	// ErrorNoWords ends a game when the room's decks can't make another question
+	ErrorNoWords ErrorCode = "no-words"
+	// ErrorUnsupportedVersion turns away a client whose protocol version is too old
+	ErrorUnsupportedVersion ErrorCode = "unsupported-version"
+	// ErrorQuestionClosed rejects an answer to a question that has closed or was never asked
+	ErrorQuestionClosed ErrorCode = "question-closed"
+	// ErrorInvalidName means the player's name can't be used. They can send their details again with another.
+	ErrorInvalidName ErrorCode = "invalid-name"
+	// ErrorInvalidIcon means the player's icon isn't in the catalogue. They can send their details again.
+	ErrorInvalidIcon ErrorCode = "invalid-icon"
+)
@@ -0,0 +1,79 @@
+package model
+
+// ProtocolVersion is the version of the messages in this package. It goes up whenever a change would break
+// clients written for an earlier version.
+//
+// Version 2 added question IDs, which answers must echo.
+const ProtocolVersion = 2
+
+// MinProtocolVersion is the oldest version the server still speaks
+const MinProtocolVersion = 2
+
+// Hello is the first message each side sends. Version is the newest protocol version the sender speaks, and
+// Capabilities lists the kinds of message it can handle. The server replies with the version it will use.
+type Hello struct {
+	Version      int
+	Capabilities []string
+}
+
+// Kinds of message, named after the fields of MessageToPlayer and MessageFromPlayer
+const (
+	KindHello             = "Hello"
+	KindPlayerDetailsReq  = "PlayerDetailsReq"
+	KindWelcome           = "Welcome"
+	KindHost              = "Host"
+	KindAboutToStart      = "AboutToStart"
+	KindPresentQuestion   = "PresentQuestion"
+	KindPlayerResult      = "PlayerResult"
+	KindRoundSummary      = "RoundSummary"
+	KindSummary           = "Summary"
+	KindError             = "Error"
+	KindPlayerDetailsResp = "PlayerDetailsResp"
+	KindPlayerResponse    = "PlayerResponse"
+)
+
+// Kind returns the kind of the message, or an empty string if it is empty
+func (m MessageToPlayer) Kind() string {
+	switch {
+	case m.Hello != nil:
+		return KindHello
+	case m.PlayerDetailsReq != nil:
+		return KindPlayerDetailsReq
+	case m.Welcome != nil:
+		return KindWelcome
+	case m.Host != nil:
+		return KindHost
+	case m.AboutToStart != nil:
+		return KindAboutToStart
+	case m.PresentQuestion != nil:
+		return KindPresentQuestion
+	case m.PlayerResult != nil:
+		return KindPlayerResult
+	case m.RoundSummary != nil:
+		return KindRoundSummary
+	case m.Summary != nil:
+		return KindSummary
+	case m.Error != nil:
+		return KindError
+	default:
+		return ""
+	}
+}
+
+// Kind returns the kind of the message, or an empty string if it is empty or of a kind this version doesn't know.
+// Disconnected never comes from a client, so it has no kind.
+func (m MessageFromPlayer) Kind() string {
+	switch {
+	case m.Hello != nil:
+		return KindHello
+	case m.PlayerDetailsResp != nil:
+		return KindPlayerDetailsResp
+	case m.PlayerResponse != nil:
+		return KindPlayerResponse
+	default:
+		return ""
+	}
+}
+
+// ServerCapabilities are the kinds of message the server handles from clients
+var ServerCapabilities = []string{KindHello, KindPlayerDetailsResp, KindPlayerResponse}
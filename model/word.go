@@ -3,19 +3,23 @@ package model
 import "fmt"
 
 type Word struct {
-	Word       string
-	WordType   string
-	Definition string
-	URL        string
+	Word       string `yaml:"word"`
+	WordType   string `yaml:"wordType"`
+	Definition string `yaml:"definition"`
+	URL        string `yaml:"url,omitempty"`
+	// Source names where the word came from, eg "merriam-webster" or "wiktionary"
+	Source string `yaml:"source,omitempty"`
 }
 
+// NewFromStringSlice creates a word from a CSV record. Older caches have fewer columns, so
+// only the columns that are present get filled in.
 func NewFromStringSlice(stringSlice []string) Word {
-	return Word{
-		Word:       stringSlice[0],
-		WordType:   stringSlice[1],
-		Definition: stringSlice[2],
-		URL:        stringSlice[3],
+	var word Word
+	fields := []*string{&word.Word, &word.WordType, &word.Definition, &word.URL, &word.Source}
+	for i := 0; i < len(fields) && i < len(stringSlice); i++ {
+		*fields[i] = stringSlice[i]
 	}
+	return word
 }
 
 func (d Word) String() string {
@@ -23,5 +27,5 @@ func (d Word) String() string {
 }
 
 func (d Word) ToStringSlice() []string {
-	return []string{d.Word, d.WordType, d.Definition, d.URL}
+	return []string{d.Word, d.WordType, d.Definition, d.URL, d.Source}
 }
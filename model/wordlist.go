@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"strings"
+)
+
+// ReadWordList reads a whole word list in the given format, which is "csv", "json" or "yaml". A file extension
+// such as ".csv" is also accepted. The formats are:
+//
+//	csv  - one word per line: word, word type, definition, and optionally URL and source
+//	json - an array of objects in the Word shape
+//	yaml - a list of objects with word, wordType, definition, and optionally url and source
+func ReadWordList(reader io.Reader, format string) (Words, error) {
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "csv":
+		return readCSVWords(reader)
+	case "json":
+		var words Words
+		err := json.NewDecoder(reader).Decode(&words)
+		return words, err
+	case "yaml", "yml":
+		var words Words
+		err := yaml.NewDecoder(reader).Decode(&words)
+		return words, err
+	default:
+		return nil, fmt.Errorf("unsupported word list format %q", format)
+	}
+}
+
+func readCSVWords(reader io.Reader) (Words, error) {
+	csvReader := csv.NewReader(reader)
+	// The URL and source columns are optional
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	var words Words
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return words, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("record %d: expected at least word, word type and definition", len(words)+1)
+		}
+		words = append(words, NewFromStringSlice(record))
+	}
+}
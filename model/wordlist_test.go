@@ -0,0 +1,49 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadWordList_CSV(t *testing.T) {
+	csv := "synergy,noun,working together\n\"circle back\", verb,\"to return to a topic later\",http://circle,team\n"
+
+	got, err := ReadWordList(strings.NewReader(csv), ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Words{
+		{Word: "synergy", WordType: "noun", Definition: "working together"},
+		{Word: "circle back", WordType: "verb", Definition: "to return to a topic later", URL: "http://circle", Source: "team"},
+	}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("Got %v and expected %v", got, expected)
+	}
+}
+
+func TestReadWordList_CSVTooFewColumns(t *testing.T) {
+	_, err := ReadWordList(strings.NewReader("synergy,noun\n"), "csv")
+	if err == nil {
+		t.Error("Expected an error for a record without a definition")
+	}
+}
+
+func TestReadWordList_JSON(t *testing.T) {
+	json := `[{"Word": "synergy", "WordType": "noun", "Definition": "working together"}]`
+
+	got, err := ReadWordList(strings.NewReader(json), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Word{Word: "synergy", WordType: "noun", Definition: "working together"}
+	if len(got) != 1 || got[0] != expected {
+		t.Errorf("Got %v and expected %v", got, Words{expected})
+	}
+}
+
+func TestReadWordList_UnsupportedFormat(t *testing.T) {
+	_, err := ReadWordList(strings.NewReader(""), ".txt")
+	if err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
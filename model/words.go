@@ -1,20 +1,19 @@
 package model
 
-import "math/rand"
+import (
+	"math/rand"
+	"sort"
+)
 
 // Words is simply a slice of Word, with handy methods
 type Words []Word
 
-// PickRandomType returns one of four random word types
-func PickRandomType() string {
-	wordTypes := []string{"noun", "adjective", "verb", "adverb"}
-	randomIndex := rand.Intn(len(wordTypes))
-	return wordTypes[randomIndex]
-}
+// WordsByType holds words grouped by their word type, eg "noun" or "noun phrase"
+type WordsByType map[string]Words
 
 // GroupByType groups this word slice into a map keyed by the type
-func (words Words) GroupByType() map[string]Words {
-	wordsByType := make(map[string]Words)
+func (words Words) GroupByType() WordsByType {
+	wordsByType := make(WordsByType)
 
 	for _, word := range words {
 		wordsByType[word.WordType] = append(wordsByType[word.WordType], word)
@@ -23,6 +22,51 @@ func (words Words) GroupByType() map[string]Words {
 	return wordsByType
 }
 
+// UsableTypes returns the word types that have at least minWords words, in alphabetical order
+func (wordsByType WordsByType) UsableTypes(minWords int) []string {
+	usableTypes := make([]string, 0, len(wordsByType))
+	for wordType, words := range wordsByType {
+		if len(words) >= minWords && len(words) > 0 {
+			usableTypes = append(usableTypes, wordType)
+		}
+	}
+	// Map order is random, so sort to make the picks repeatable for a given seed
+	sort.Strings(usableTypes)
+	return usableTypes
+}
+
+// PickRandomType picks a random word type that has at least minWords words. Types with more words are
+// more likely to be picked. It returns false if no type has enough words.
+func (wordsByType WordsByType) PickRandomType(minWords int) (string, bool) {
+	usableTypes := wordsByType.UsableTypes(minWords)
+
+	total := 0
+	for _, wordType := range usableTypes {
+		total += len(wordsByType[wordType])
+	}
+	if total == 0 {
+		return "", false
+	}
+
+	pick := rand.Intn(total)
+	for _, wordType := range usableTypes {
+		pick -= len(wordsByType[wordType])
+		if pick < 0 {
+			return wordType, true
+		}
+	}
+	return "", false
+}
+
+// All returns every word, regardless of type
+func (wordsByType WordsByType) All() Words {
+	var all Words
+	for _, wordType := range wordsByType.UsableTypes(0) {
+		all = append(all, wordsByType[wordType]...)
+	}
+	return all
+}
+
 // PickRandomWords will pick n unique random words from this word slice. If it
 // happens to pick the same word twice, it will re-pick until a unique word is picked.
 func (words Words) PickRandomWords(numberToChoose int) Words {
@@ -12,12 +12,56 @@ var sampleWords = Words{
 	Word{Word: "four", WordType: "noun"},
 }
 
-func TestWords_PickRandomType(t *testing.T) {
+var sampleWordsByType = WordsByType{
+	"noun":        sampleWords,
+	"noun phrase": Words{{Word: "five", WordType: "noun phrase"}, {Word: "six", WordType: "noun phrase"}},
+	"adverb":      Words{{Word: "seven", WordType: "adverb"}},
+}
+
+func TestWordsByType_PickRandomType(t *testing.T) {
 	rand.Seed(1)
-	got := PickRandomType()
-	expected := "adjective"
-	if got != expected {
-		t.Errorf("Got %s and expected %s", got, expected)
+
+	picks := make(map[string]int)
+	for i := 0; i < 600; i++ {
+		wordType, ok := sampleWordsByType.PickRandomType(2)
+		if !ok {
+			t.Fatal("Expected a word type to be picked")
+		}
+		picks[wordType]++
+	}
+
+	if picks["adverb"] != 0 {
+		t.Errorf("Picked adverb %d times, but it doesn't have enough words", picks["adverb"])
+	}
+	// Nouns have twice as many words as noun phrases, so should be picked about twice as often
+	if picks["noun"] < 300 || picks["noun phrase"] < 100 {
+		t.Errorf("Expected picks weighted by the number of words, got %v", picks)
+	}
+}
+
+func TestWordsByType_PickRandomType_NoneUsable(t *testing.T) {
+	_, ok := sampleWordsByType.PickRandomType(5)
+	if ok {
+		t.Error("Expected no word type to have enough words")
+	}
+
+	_, ok = WordsByType{}.PickRandomType(0)
+	if ok {
+		t.Error("Expected no word type to be picked from no words")
+	}
+}
+
+func TestWordsByType_UsableTypes(t *testing.T) {
+	got := sampleWordsByType.UsableTypes(2)
+	if len(got) != 2 || got[0] != "noun" || got[1] != "noun phrase" {
+		t.Errorf("Got %v and expected [noun noun phrase]", got)
+	}
+}
+
+func TestWordsByType_All(t *testing.T) {
+	got := sampleWordsByType.All()
+	if len(got) != 7 {
+		t.Errorf("Got %d words and expected 7", len(got))
 	}
 }
 
@@ -54,3 +98,18 @@ func TestWords_PickRandomWords_PickTooMany(t *testing.T) {
 		t.Errorf("Got length %d and expected %d", len(got), expectedLength)
 	}
 }
+
+func TestWord_NewFromStringSlice(t *testing.T) {
+	got := NewFromStringSlice([]string{"one", "noun", "the first", "http://one", "wiktionary"})
+	expected := Word{Word: "one", WordType: "noun", Definition: "the first", URL: "http://one", Source: "wiktionary"}
+	if got != expected {
+		t.Errorf("Got %v and expected %v", got, expected)
+	}
+
+	// Caches written before the Source column existed only have four columns
+	got = NewFromStringSlice([]string{"one", "noun", "the first", "http://one"})
+	expected = Word{Word: "one", WordType: "noun", Definition: "the first", URL: "http://one"}
+	if got != expected {
+		t.Errorf("Got %v and expected %v", got, expected)
+	}
+}
@@ -0,0 +1,126 @@
+// Package names checks the names players pick, so they can be shown to everyone in the game
+package names
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxLength is the longest name, in characters, when no other is configured
+const DefaultMaxLength = 20
+
+var (
+	// ErrBlank is returned for names with nothing but spaces in them
+	ErrBlank = errors.New("names can't be blank")
+	// ErrInvalidCharacters is returned for names with control or other unprintable characters
+	ErrInvalidCharacters = errors.New("names can only have printable characters")
+	// ErrBlocked is returned for names with a blocked word in them
+	ErrBlocked = errors.New("names can't have offensive words")
+)
+
+// DefaultBlockedWords are the words kept out of names when no other list is configured. It is a short list of the
+// most common ones, and servers open to the public will want a fuller list of their own.
+var DefaultBlockedWords = []string{
+	"arse", "arsehole", "ass", "asshole", "bastard", "bitch", "bollocks", "cock", "crap", "cunt", "dick", "fag",
+	"faggot", "fuck", "fucker", "fucking", "motherfucker", "nazi", "nigga", "nigger", "piss", "prick", "pussy",
+	"retard", "shit", "slut", "twat", "wank", "wanker", "whore",
+}
+
+// lookalikes are characters used in place of letters to get around the filter
+var lookalikes = strings.NewReplacer("0", "o", "1", "i", "!", "i", "3", "e", "4", "a", "@", "a", "5", "s",
+	"$", "s", "7", "t")
+
+// Checker checks names against a length limit and a list of blocked words
+type Checker struct {
+	maxLength int
+	blocked   map[string]bool
+}
+
+// NewChecker creates a checker for names up to maxLength characters, without any of the blocked words
+func NewChecker(maxLength int, blockedWords []string) *Checker {
+	blocked := make(map[string]bool, len(blockedWords))
+	for _, word := range blockedWords {
+		blocked[normalise(word)] = true
+	}
+	return &Checker{
+		maxLength: maxLength,
+		blocked:   blocked,
+	}
+}
+
+// Check returns the name without surrounding spaces, or an error saying why it can't be used
+func (c *Checker) Check(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrBlank
+	}
+	if utf8.RuneCountInString(name) > c.maxLength {
+		return "", fmt.Errorf("names can't be longer than %d characters", c.maxLength)
+	}
+	for _, r := range name {
+		if !unicode.IsPrint(r) || r == utf8.RuneError {
+			return "", ErrInvalidCharacters
+		}
+	}
+	if c.isBlocked(name) {
+		return "", ErrBlocked
+	}
+	return name, nil
+}
+
+// Unique returns the name, or if it is already taken, the name with the lowest number after it that isn't, eg
+// "Karl 2". Names are compared ignoring case. The name is shortened if need be to fit the number on.
+func (c *Checker) Unique(name string, taken func(name string) bool) string {
+	if !taken(name) {
+		return name
+	}
+	for n := 2; ; n++ {
+		suffix := fmt.Sprintf(" %d", n)
+		base := []rune(name)
+		if room := c.maxLength - len(suffix); len(base) > room && room > 0 {
+			base = base[:room]
+		}
+		candidate := strings.TrimSpace(string(base)) + suffix
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// isBlocked looks for blocked words in the name, one word at a time and then with the spaces and punctuation
+// between the words taken out, to catch names like "s.h.i.t"
+func (c *Checker) isBlocked(name string) bool {
+	name = normalise(name)
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		if c.blocked[word] {
+			return true
+		}
+	}
+	return c.blocked[strings.Join(words, "")]
+}
+
+// normalise lowercases the name and swaps lookalike characters for the letters they stand for
+func normalise(name string) string {
+	return lookalikes.Replace(strings.ToLower(name))
+}
+
+// ReadWordList reads blocked words, one per line. Blank lines and lines starting with # are skipped.
+func ReadWordList(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
@@ -0,0 +1,63 @@
+package names
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecker_Check(t *testing.T) {
+	checker := NewChecker(10, []string{"darn", "heck"})
+
+	tests := []struct {
+		name     string
+		expected string
+		ok       bool
+	}{
+		{"  Karl ", "Karl", true},
+		{"Zoë", "Zoë", true},
+		{"", "", false},
+		{"   ", "", false},
+		{"Elevenchars", "", false},
+		{"bell\x07", "", false},
+		{"DARN", "", false},
+		{"oh h3ck", "", false},
+		{"d.a.r.n", "", false},
+		{"darnell", "darnell", true},
+	}
+	for _, test := range tests {
+		got, err := checker.Check(test.name)
+		if got != test.expected || (err == nil) != test.ok {
+			t.Errorf("%q: Got %q, %v and expected %q", test.name, got, err, test.expected)
+		}
+	}
+}
+
+func TestChecker_Unique(t *testing.T) {
+	checker := NewChecker(8, nil)
+	names := map[string]bool{"karl": true, "karl 2": true, "santana": true}
+	taken := func(name string) bool {
+		return names[strings.ToLower(name)]
+	}
+
+	tests := map[string]string{
+		"Bob":      "Bob",
+		"Karl":     "Karl 3",
+		"Santana":  "Santan 2",
+		"Santanas": "Santanas",
+	}
+	for name, expected := range tests {
+		if got := checker.Unique(name, taken); got != expected {
+			t.Errorf("Got %q and expected %q", got, expected)
+		}
+	}
+}
+
+func TestReadWordList(t *testing.T) {
+	words, err := ReadWordList(strings.NewReader("# Words to keep out\ndarn\n\n  heck  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 2 || words[0] != "darn" || words[1] != "heck" {
+		t.Errorf("Got %v and expected [darn heck]", words)
+	}
+}
@@ -0,0 +1,51 @@
+package player
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"sync/atomic"
+)
+
+// maxQueueDepth is the deepest any send queue has been
+var maxQueueDepth int64
+
+var (
+	connectedPlayers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "connected_players",
+		Help:      "Number of open player connections.",
+	})
+	disconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "player_disconnects_total",
+		Help:      "Number of player connections that have closed, for any reason.",
+	})
+	queuedMessages = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "send_queue_messages",
+		Help:      "Number of messages waiting to be written, across all players.",
+	})
+	droppedMessages = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "send_queue_dropped_total",
+		Help:      "Number of stale messages dropped to make room in a full send queue.",
+	})
+	overflowDisconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "send_queue_overflow_disconnects_total",
+		Help:      "Number of players disconnected because they couldn't keep up with their send queue.",
+	})
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "send_queue_max_depth",
+		Help:      "Deepest any player's send queue has been.",
+	}, func() float64 {
+		return float64(atomic.LoadInt64(&maxQueueDepth))
+	})
+	sendDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "wordofthedaygame",
+		Name:      "websocket_send_seconds",
+		Help:      "Time taken to write a message to a player's websocket.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 9),
+	})
+)
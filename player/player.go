@@ -1,24 +1,27 @@
 package player
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/gorilla/websocket"
+	"github.com/ksanta/wordofthedaygame/codec"
 	"github.com/ksanta/wordofthedaygame/model"
 	"log"
 	"os"
+	"sync"
 	"time"
 )
 
 // Player sits between the Game and the Websocket connection. It monitors the
 // Websocket connection for incoming messages and forwards them to the game via a
-// channel. It monitors a channel from the game and sends messages out the Websocket
+// channel. Messages from the game are queued with Send and written out the Websocket
 // connection.
 type Player struct {
 	// Embedded Logger allows the Player struct to attach Logger methods
 	*log.Logger
 	// The Websocket connection
 	conn *websocket.Conn
+	// codec encodes the messages, as agreed by the websocket subprotocol
+	codec codec.Codec
 	// Whether the player has an active connection. Connection could go dead mid-game
 	// and the show must go on!
 	Active bool
@@ -28,16 +31,54 @@ type Player struct {
 	disconnectChan chan struct{}
 	// Posting here will send the message to the game hub
 	sendToGameChan chan PlayerMessage
-	// SendToClientChan will send received messages to the Websocket connection
-	SendToClientChan chan model.MessageToPlayer
+	// Messages waiting to be written to the Websocket connection
+	queue *sendQueue
 	// Name of this player
 	name string
 	// Client-specific icon to represent the player
 	Icon string
+	// Account is the username of the account the player logged in with, or blank for a guest
+	Account string
+	// AccountDetails are the name and icon the account prefers, used when the player doesn't give their own
+	AccountDetails model.PlayerDetails
+	// DetailsRequest is sent after the hello, to clients that handle it, asking for the player's details
+	DetailsRequest *model.PlayerDetailsReq
 	// Points for this player
 	points int
-	// Time tracks when a player started to answer a question
-	startTime time.Time
+	// Keepalive and deadline settings for the connection
+	settings ConnectionSettings
+	// capabilities are the kinds of message the client said it handles in its Hello. They are nil until the
+	// client says hello, and until then it is only sent errors.
+	capabilities     map[string]bool
+	capabilitiesLock sync.Mutex
+}
+
+// ConnectionSettings control how the Websocket connection is kept alive and when it is considered dead
+type ConnectionSettings struct {
+	// PongWait is how long to wait for a message or a pong from the client before giving up on the connection
+	PongWait time.Duration
+	// PingPeriod is how often the client is pinged. It must be less than PongWait.
+	PingPeriod time.Duration
+	// WriteWait is how long a single write to the client may take
+	WriteWait time.Duration
+	// MaxMessageSize is the largest message, in bytes, that the client may send
+	MaxMessageSize int64
+	// SendQueueSize is the number of messages that can wait to be written before the OverflowPolicy kicks in
+	SendQueueSize int
+	// OverflowPolicy decides what to do when the send queue is full
+	OverflowPolicy OverflowPolicy
+}
+
+// DefaultConnectionSettings returns settings that notice a dead connection within a minute
+func DefaultConnectionSettings() ConnectionSettings {
+	return ConnectionSettings{
+		PongWait:       60 * time.Second,
+		PingPeriod:     54 * time.Second,
+		WriteWait:      10 * time.Second,
+		MaxMessageSize: 4096,
+		SendQueueSize:  32,
+		OverflowPolicy: DropStale,
+	}
 }
 
 // PlayerMessage is sent from the Player to the Game, so the player knows which
@@ -47,47 +88,182 @@ type PlayerMessage struct {
 	Message model.MessageFromPlayer
 }
 
-func NewPlayer(conn *websocket.Conn, disconnectChan chan struct{}, sendToGameChan chan PlayerMessage) *Player {
+func NewPlayer(conn *websocket.Conn,
+	disconnectChan chan struct{},
+	sendToGameChan chan PlayerMessage,
+	settings ConnectionSettings) *Player {
+
+	// Pings must go out before the client is given up on
+	if settings.PingPeriod <= 0 || settings.PingPeriod >= settings.PongWait {
+		settings.PingPeriod = settings.PongWait * 9 / 10
+	}
+
 	return &Player{
-		Logger:           log.New(os.Stdout, "[New player] ", 0),
-		conn:             conn,
-		disconnectChan:   disconnectChan,
-		sendToGameChan:   sendToGameChan,
-		SendToClientChan: make(chan model.MessageToPlayer),
-		name:             "New player",
+		Logger:         log.New(os.Stdout, "[New player] ", 0),
+		conn:           conn,
+		codec:          codec.ForSubprotocol(conn.Subprotocol()),
+		disconnectChan: disconnectChan,
+		sendToGameChan: sendToGameChan,
+		queue:          newSendQueue(settings.SendQueueSize, settings.OverflowPolicy),
+		name:           "New player",
+		settings:       settings,
 	}
 }
 
-// WritePump listens on channels and writes messages to the Websocket connection.
+// WritePump takes messages from the send queue and writes them to the Websocket connection.
+// It also pings the client regularly, so a dead connection is noticed by ReadPump.
 // This is to be started as a goroutine.
 func (p *Player) WritePump() {
+	ticker := time.NewTicker(p.settings.PingPeriod)
+
 	defer func() {
+		ticker.Stop()
+		// Anything still queued can't be delivered now
+		p.queue.discard()
 		p.Println("Closing TCP connection")
 		p.disconnectChan <- struct{}{}
 	}()
 
 	for {
-		message, ok := <-p.SendToClientChan
-		if !ok {
-			// The hub closed the channel.
-			p.Println("Sending close message")
-			p.conn.WriteMessage(websocket.CloseMessage, []byte{})
-			return
-		}
-		err := p.sendJSON(message)
-		if err != nil {
-			p.Println("SendJSON error:", err)
-			return
+		select {
+		case <-p.queue.ready:
+			for {
+				message, ok, closed := p.queue.pop()
+				if closed {
+					// The game closed the player
+					p.Println("Sending close message")
+					p.conn.SetWriteDeadline(time.Now().Add(p.settings.WriteWait))
+					p.conn.WriteMessage(websocket.CloseMessage, p.queue.closeMessage())
+					return
+				}
+				if !ok {
+					break
+				}
+
+				p.conn.SetWriteDeadline(time.Now().Add(p.settings.WriteWait))
+				err := p.sendMessage(message)
+				if err != nil {
+					p.Println("Send error:", err)
+					return
+				}
+			}
+
+		case <-ticker.C:
+			p.conn.SetWriteDeadline(time.Now().Add(p.settings.WriteWait))
+			err := p.conn.WriteMessage(websocket.PingMessage, nil)
+			if err != nil {
+				p.Println("Ping error:", err)
+				return
+			}
 		}
 	}
 }
 
+// Send queues a message to be written to the client. It never blocks. If the client can't keep up and
+// the queue overflows, the player is disconnected and the game hears about it as a normal disconnect.
+// Messages of a kind the client hasn't said it handles are dropped.
+func (p *Player) Send(message model.MessageToPlayer) {
+	if !p.handles(message.Kind()) {
+		return
+	}
+	if p.queue.push(message) {
+		return
+	}
+
+	p.Println("Disconnecting player who can't keep up with", p.queue.depth(), "messages queued")
+	overflowDisconnects.Inc()
+	p.queue.discard()
+	// Closing the connection makes ReadPump fail, which tells the game the player has gone
+	p.conn.Close()
+}
+
+// Close tells the player that the game has finished with them. Messages already sent are written to the
+// client before the connection is closed with a normal closure.
+func (p *Player) Close() {
+	p.queue.close(websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// handles returns whether the client can handle a kind of message. A Hello is always sent in reply to the
+// client's, since that is how it learns which version the server speaks.
+func (p *Player) handles(kind string) bool {
+	p.capabilitiesLock.Lock()
+	defer p.capabilitiesLock.Unlock()
+	return p.capabilities == nil || kind == model.KindHello || p.capabilities[kind]
+}
+
+// saidHello returns whether the client has said hello
+func (p *Player) saidHello() bool {
+	p.capabilitiesLock.Lock()
+	defer p.capabilitiesLock.Unlock()
+	return p.capabilities != nil
+}
+
+// rejectVersion tells the client its protocol version isn't supported and closes the connection
+func (p *Player) rejectVersion(message string) {
+	p.Send(model.MessageToPlayer{
+		Error: &model.GameError{
+			Code:    model.ErrorUnsupportedVersion,
+			Message: message,
+		},
+	})
+	p.queue.close(websocket.FormatCloseMessage(websocket.CloseProtocolError, "unsupported protocol version"))
+}
+
+// handleHello records the kinds of message the client handles, and replies with the protocol version that
+// will be used. It returns false if the client is too old to talk to, in which case the connection is closed.
+func (p *Player) handleHello(hello *model.Hello) bool {
+	if hello.Version < model.MinProtocolVersion {
+		p.Println("Rejecting client with protocol version", hello.Version)
+		p.rejectVersion(fmt.Sprintf("Protocol version %d is not supported. The oldest supported is %d.",
+			hello.Version, model.MinProtocolVersion))
+		return false
+	}
+
+	capabilities := make(map[string]bool)
+	for _, kind := range hello.Capabilities {
+		capabilities[kind] = true
+	}
+	p.capabilitiesLock.Lock()
+	p.capabilities = capabilities
+	p.capabilitiesLock.Unlock()
+
+	version := hello.Version
+	if version > model.ProtocolVersion {
+		version = model.ProtocolVersion
+	}
+	p.Send(model.MessageToPlayer{
+		Hello: &model.Hello{
+			Version:      version,
+			Capabilities: model.ServerCapabilities,
+		},
+	})
+	if p.DetailsRequest != nil {
+		p.Send(model.MessageToPlayer{PlayerDetailsReq: p.DetailsRequest})
+	}
+	return true
+}
+
+// CloseGoingAway is like Close, but tells the client the server is going away, eg because it is shutting down
+func (p *Player) CloseGoingAway(reason string) {
+	p.queue.close(websocket.FormatCloseMessage(websocket.CloseGoingAway, reason))
+}
+
+// QueueDepth returns the number of messages waiting to be written to the client
+func (p *Player) QueueDepth() int {
+	return p.queue.depth()
+}
+
 // ReadPump listens to the Websocket connection and delegates handling of messages.
 // This is to be started as a goroutine.
 func (p *Player) ReadPump() {
+	connectedPlayers.Inc()
+
 	defer func() {
+		connectedPlayers.Dec()
+		disconnects.Inc()
+
 		// Notify the game that the player disconnected. That will handle shutdown.
-		p.Println("Unregistering", p.name)
+		p.Println("Unregistering")
 		p.sendToGameChan <- PlayerMessage{
 			Player: p,
 			Message: model.MessageFromPlayer{
@@ -96,8 +272,17 @@ func (p *Player) ReadPump() {
 		}
 	}()
 
+	// The read deadline is pushed back whenever the client shows it is alive. If it passes, the
+	// read fails and the player is disconnected like any other lost connection.
+	p.conn.SetReadLimit(p.settings.MaxMessageSize)
+	p.extendReadDeadline()
+	p.conn.SetPongHandler(func(string) error {
+		p.extendReadDeadline()
+		return nil
+	})
+
 	for {
-		message, err := p.receiveJSON()
+		message, err := p.receiveMessage()
 		if err != nil {
 			// Client closed the connection
 			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
@@ -105,6 +290,26 @@ func (p *Player) ReadPump() {
 			}
 			return
 		}
+		p.extendReadDeadline()
+
+		if message.Hello != nil {
+			if !p.handleHello(message.Hello) {
+				return
+			}
+			continue
+		}
+		if message.Kind() == "" {
+			// Newer clients may send kinds of message this server doesn't know about
+			continue
+		}
+		if !p.saidHello() {
+			// Clients written before the hello never send the IDs of the questions they answer, so they can't score
+			p.Println("Rejecting client that didn't say hello")
+			p.rejectVersion(fmt.Sprintf("Clients must say hello before anything else, with a protocol version of "+
+				"at least %d.", model.MinProtocolVersion))
+			return
+		}
+
 		p.sendToGameChan <- PlayerMessage{
 			Player:  p,
 			Message: message,
@@ -112,6 +317,10 @@ func (p *Player) ReadPump() {
 	}
 }
 
+func (p *Player) extendReadDeadline() {
+	p.conn.SetReadDeadline(time.Now().Add(p.settings.PongWait))
+}
+
 func (p *Player) AddPoints(points int) {
 	p.points += points
 }
@@ -129,23 +338,17 @@ func (p *Player) GetName() string {
 	return p.name
 }
 
-func (p *Player) StartTimer() {
-	p.startTime = time.Now()
-}
-
-func (p *Player) StopTimer() time.Duration {
-	return time.Since(p.startTime)
-}
-
-func (p *Player) sendJSON(request model.MessageToPlayer) error {
-	jsonBytes, err := json.Marshal(request)
+func (p *Player) sendMessage(message model.MessageToPlayer) error {
+	data, err := p.codec.Marshal(message)
 	if err != nil {
 		return err
 	}
 
-	p.Print("<- ", string(jsonBytes))
+	p.logMessage("<- ", data)
 
-	err = p.conn.WriteMessage(websocket.TextMessage, jsonBytes)
+	start := time.Now()
+	err = p.conn.WriteMessage(p.codec.FrameType(), data)
+	sendDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
 		return err
 	}
@@ -153,26 +356,36 @@ func (p *Player) sendJSON(request model.MessageToPlayer) error {
 	return nil
 }
 
-func (p *Player) receiveJSON() (model.MessageFromPlayer, error) {
-	_, jsonBytes, err := p.conn.ReadMessage()
+func (p *Player) receiveMessage() (model.MessageFromPlayer, error) {
+	_, data, err := p.conn.ReadMessage()
 	if err != nil {
 		return model.MessageFromPlayer{}, err
 	}
-	p.Print("-> ", string(jsonBytes))
+	p.logMessage("-> ", data)
 
-	var response model.MessageFromPlayer
-	err = json.Unmarshal(jsonBytes, &response)
+	var message model.MessageFromPlayer
+	err = p.codec.Unmarshal(data, &message)
 	if err != nil {
 		return model.MessageFromPlayer{}, err
 	}
-	return response, nil
+	return message, nil
+}
+
+// logMessage logs text messages as they are, and binary ones by their size
+func (p *Player) logMessage(direction string, data []byte) {
+	if p.codec.FrameType() == websocket.TextMessage {
+		p.Print(direction, string(data))
+	} else {
+		p.Printf("%s%d bytes of %s", direction, len(data), p.codec.Name())
+	}
 }
 
 func (p *Player) PlayerState() model.PlayerState {
 	return model.PlayerState{
-		Name:   p.name,
-		Score:  p.GetPoints(),
-		Active: p.Active,
-		Icon:   p.Icon,
+		Name:    p.name,
+		Score:   p.GetPoints(),
+		Active:  p.Active,
+		Icon:    p.Icon,
+		Account: p.Account,
 	}
 }
@@ -0,0 +1,410 @@
+package player
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/ksanta/wordofthedaygame/codec"
+	"github.com/ksanta/wordofthedaygame/model"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSettings = ConnectionSettings{
+	PongWait:       200 * time.Millisecond,
+	PingPeriod:     50 * time.Millisecond,
+	WriteWait:      100 * time.Millisecond,
+	MaxMessageSize: 512,
+}
+
+// startPlayerServer starts a server that connects each websocket to a new Player. It returns the channel the
+// players send their messages to the game on, and a channel that receives each new Player.
+func startPlayerServer(t *testing.T, settings ConnectionSettings) (*httptest.Server, chan PlayerMessage, chan *Player) {
+	t.Helper()
+	return startPlayerServerWith(t, settings, func(*Player) {})
+}
+
+// startPlayerServerWith is startPlayerServer, with setup called on each player before it starts reading
+func startPlayerServerWith(t *testing.T, settings ConnectionSettings, setup func(*Player)) (*httptest.Server,
+	chan PlayerMessage, chan *Player) {
+	t.Helper()
+	gameChan := make(chan PlayerMessage, 10)
+	playerChan := make(chan *Player, 10)
+	upgrader := websocket.Upgrader{Subprotocols: codec.Subprotocols()}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		disconnectChan := make(chan struct{})
+		p := NewPlayer(conn, disconnectChan, gameChan, settings)
+		setup(p)
+		playerChan <- p
+		go p.ReadPump()
+		go p.WritePump()
+
+		<-disconnectChan
+	}))
+
+	return server, gameChan, playerChan
+}
+
+func dial(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// waitForDisconnect waits for the player to tell the game it has disconnected
+func waitForDisconnect(gameChan chan PlayerMessage, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case message := <-gameChan:
+			if message.Message.Disconnected != nil {
+				// The game closes the channel once it has handled the disconnect
+				message.Player.Close()
+				return true
+			}
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func TestPlayer_MissedPongsDisconnect(t *testing.T) {
+	server, gameChan, _ := startPlayerServer(t, testSettings)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	// A half-open connection never answers pings
+	conn.SetPingHandler(func(string) error { return nil })
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if !waitForDisconnect(gameChan, 2*time.Second) {
+		t.Error("Expected the player to be disconnected after missing pongs")
+	}
+}
+
+func TestPlayer_PongsKeepConnectionAlive(t *testing.T) {
+	server, gameChan, _ := startPlayerServer(t, testSettings)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	// The default ping handler answers with a pong, but only while reading
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if waitForDisconnect(gameChan, 4*testSettings.PongWait) {
+		t.Error("Expected the connection to stay alive while the client answers pings")
+	}
+}
+
+func TestPlayer_MaxMessageSize(t *testing.T) {
+	server, gameChan, _ := startPlayerServer(t, testSettings)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	err := conn.WriteJSON(model.MessageFromPlayer{
+		PlayerDetailsResp: &model.PlayerDetails{Name: strings.Repeat("x", 1000)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !waitForDisconnect(gameChan, 2*time.Second) {
+		t.Error("Expected the player to be disconnected after sending an oversized message")
+	}
+}
+
+// sendFlood sends lots of large round summaries to the player, the way the game would to a client that
+// has stopped reading, and returns how long it took
+func sendFlood(p *Player) time.Duration {
+	bigSummary := model.MessageToPlayer{RoundSummary: &model.RoundSummary{}}
+	for i := 0; i < 100; i++ {
+		bigSummary.RoundSummary.PlayerStates = append(bigSummary.RoundSummary.PlayerStates, model.PlayerState{
+			Name: strings.Repeat("x", 1000),
+		})
+	}
+
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		p.Send(bigSummary)
+	}
+	return time.Since(start)
+}
+
+func TestPlayer_StalledClientDoesNotBlockSend(t *testing.T) {
+	for _, policy := range []OverflowPolicy{DropStale, Disconnect} {
+		settings := testSettings
+		settings.SendQueueSize = 8
+		settings.OverflowPolicy = policy
+		server, gameChan, playerChan := startPlayerServer(t, settings)
+
+		conn := dial(t, server)
+		p := <-playerChan
+
+		// The client never reads, so the server's writes back up once the network buffers are full.
+		// With 100MB of messages, sending would block for good if it waited on the client.
+		elapsed := sendFlood(p)
+		if elapsed > time.Second {
+			t.Errorf("Policy %d: sending to a stalled client took %s", policy, elapsed)
+		}
+
+		// Either the queue overflowed or the writes timed out. Either way, the game hears about it.
+		if !waitForDisconnect(gameChan, 2*time.Second) {
+			t.Errorf("Policy %d: expected the stalled player to be disconnected", policy)
+		}
+
+		conn.Close()
+		server.Close()
+	}
+}
+
+func TestPlayer_HelloLimitsMessageKinds(t *testing.T) {
+	server, gameChan, playerChan := startPlayerServer(t, testSettings)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+	p := <-playerChan
+
+	err := conn.WriteJSON(model.MessageFromPlayer{
+		Hello: &model.Hello{Version: model.ProtocolVersion, Capabilities: []string{model.KindSummary}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reply model.MessageToPlayer
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Hello == nil || reply.Hello.Version != model.ProtocolVersion {
+		t.Fatalf("Got %+v and expected a Hello with version %d", reply, model.ProtocolVersion)
+	}
+
+	// The client only handles summaries, so the round summary is dropped
+	p.Send(model.MessageToPlayer{RoundSummary: &model.RoundSummary{}})
+	p.Send(model.MessageToPlayer{Summary: &model.Summary{Winner: "Amy"}})
+
+	var message model.MessageToPlayer
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Kind() != model.KindSummary {
+		t.Errorf("Got %s and expected %s", message.Kind(), model.KindSummary)
+	}
+
+	// The hello is handled by the player and never reaches the game
+	select {
+	case message := <-gameChan:
+		t.Errorf("Got %+v and expected nothing sent to the game", message.Message)
+	default:
+	}
+}
+
+func TestPlayer_DetailsRequestFollowsHello(t *testing.T) {
+	request := &model.PlayerDetailsReq{Icons: []model.Icon{{ID: "Horse1", URL: "/images/Horse1.png"}}}
+	settings := testSettings
+	settings.SendQueueSize = 8
+	server, _, _ := startPlayerServerWith(t, settings, func(p *Player) {
+		p.DetailsRequest = request
+	})
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	err := conn.WriteJSON(model.MessageFromPlayer{
+		Hello: &model.Hello{Version: model.ProtocolVersion, Capabilities: []string{model.KindPlayerDetailsReq}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hello, message model.MessageToPlayer
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&hello); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	if hello.Hello == nil || !reflect.DeepEqual(message.PlayerDetailsReq, request) {
+		t.Errorf("Got %+v then %+v and expected a Hello then %+v", hello, message, request)
+	}
+}
+
+func TestPlayer_UnsupportedVersionIsRejected(t *testing.T) {
+	server, gameChan, _ := startPlayerServer(t, testSettings)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	err := conn.WriteJSON(model.MessageFromPlayer{Hello: &model.Hello{Version: 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var message model.MessageToPlayer
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Error == nil || message.Error.Code != model.ErrorUnsupportedVersion {
+		t.Errorf("Got %+v and expected an %s Error", message, model.ErrorUnsupportedVersion)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseProtocolError) {
+		t.Errorf("Got %v and expected a protocol error close", err)
+	}
+
+	if !waitForDisconnect(gameChan, time.Second) {
+		t.Error("Expected the player to be disconnected")
+	}
+}
+
+func TestPlayer_UnknownMessagesAreIgnored(t *testing.T) {
+	server, gameChan, _ := startPlayerServer(t, testSettings)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	for _, message := range []string{`{"Hello":{"Version":2}}`, `{"Emote":{"Emoji":"🎉"}}`,
+		`{"PlayerResponse":{"Response":1}}`} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the message the server knows reaches the game, and the player stays connected
+	select {
+	case message := <-gameChan:
+		if message.Message.PlayerResponse == nil {
+			t.Errorf("Got %+v and expected a PlayerResponse", message.Message)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the PlayerResponse to reach the game")
+	}
+}
+
+func TestPlayer_MessagesBeforeHelloAreRejected(t *testing.T) {
+	server, gameChan, _ := startPlayerServer(t, testSettings)
+	defer server.Close()
+
+	conn := dial(t, server)
+	defer conn.Close()
+
+	err := conn.WriteJSON(model.MessageFromPlayer{PlayerResponse: &model.PlayerResponse{Response: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var message model.MessageToPlayer
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Error == nil || message.Error.Code != model.ErrorUnsupportedVersion {
+		t.Errorf("Got %+v and expected an %s Error", message, model.ErrorUnsupportedVersion)
+	}
+
+	// The response never reaches the game
+	select {
+	case message := <-gameChan:
+		if message.Message.Disconnected == nil {
+			t.Errorf("Got %+v and expected only a disconnect", message.Message)
+		}
+		message.Player.Close()
+	case <-time.After(time.Second):
+		t.Error("Expected the player to be disconnected")
+	}
+}
+
+func TestPlayer_MessagePackSubprotocol(t *testing.T) {
+	settings := testSettings
+	settings.SendQueueSize = 8
+	server, gameChan, playerChan := startPlayerServer(t, settings)
+	defer server.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{codec.MessagePack.Subprotocol()}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	p := <-playerChan
+
+	if conn.Subprotocol() != codec.MessagePack.Subprotocol() {
+		t.Fatalf("Got subprotocol %q and expected %q", conn.Subprotocol(), codec.MessagePack.Subprotocol())
+	}
+
+	for _, sent := range []model.MessageFromPlayer{
+		{Hello: &model.Hello{Version: model.ProtocolVersion, Capabilities: []string{model.KindWelcome}}},
+		{PlayerResponse: &model.PlayerResponse{QuestionID: 4, Response: 2}},
+	} {
+		data, err := codec.MessagePack.Marshal(sent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case message := <-gameChan:
+		if message.Message.PlayerResponse == nil || message.Message.PlayerResponse.QuestionID != 4 {
+			t.Errorf("Got %+v and expected the PlayerResponse", message.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the PlayerResponse to reach the game")
+	}
+
+	p.Send(model.MessageToPlayer{Welcome: &model.Welcome{TargetScore: 500}})
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	// The first message is the reply to the hello
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	frameType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var message model.MessageToPlayer
+	if err := codec.MessagePack.Unmarshal(data, &message); err != nil {
+		t.Fatal(err)
+	}
+	if frameType != websocket.BinaryMessage || message.Welcome == nil || message.Welcome.TargetScore != 500 {
+		t.Errorf("Got frame type %d with %+v and expected a binary Welcome", frameType, message)
+	}
+}
@@ -46,3 +46,15 @@ func (players Players) PlayerWithHighestPoints() *Player {
 
 	return winner
 }
+
+// NumWaitingForResponse returns the number of active players who haven't answered the current question
+func (players Players) NumWaitingForResponse() int {
+	waiting := 0
+
+	for _, p := range players {
+		if p.Active && p.WaitingForResponse {
+			waiting++
+		}
+	}
+	return waiting
+}
@@ -0,0 +1,173 @@
+package player
+
+import (
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/model"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what happens when a player's send queue is full
+type OverflowPolicy int
+
+const (
+	// DropStale makes room by dropping queued RoundSummary messages, since a newer summary replaces them
+	// anyway. If there are none to drop, the player is disconnected.
+	DropStale OverflowPolicy = iota
+	// Disconnect disconnects the player as soon as their queue is full
+	Disconnect
+)
+
+// ParseOverflowPolicy converts "drop-stale" or "disconnect" to an OverflowPolicy
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	switch name {
+	case "drop-stale":
+		return DropStale, nil
+	case "disconnect":
+		return Disconnect, nil
+	default:
+		return DropStale, fmt.Errorf("unknown overflow policy %q", name)
+	}
+}
+
+// sendQueue is a bounded queue of messages waiting to be written to a player's connection. Pushing to it
+// never blocks, so one slow client can't hold up the game or the other players.
+type sendQueue struct {
+	lock     sync.Mutex
+	messages []model.MessageToPlayer
+	maxSize  int
+	policy   OverflowPolicy
+	closed   bool
+	// seq is the sequence number given to the last message pushed
+	seq uint64
+	// closeFrame is the close message sent to the client once the queue has been emptied
+	closeFrame []byte
+	// ready is signalled whenever there is something for the writer to do
+	ready chan struct{}
+}
+
+func newSendQueue(maxSize int, policy OverflowPolicy) *sendQueue {
+	if maxSize < 1 {
+		maxSize = 1
+	}
+	return &sendQueue{
+		messages: make([]model.MessageToPlayer, 0, maxSize),
+		maxSize:  maxSize,
+		policy:   policy,
+		ready:    make(chan struct{}, 1),
+	}
+}
+
+// push adds a message to the queue. It returns false if the queue overflowed and the player should be
+// disconnected. Messages pushed after the queue is closed are quietly dropped.
+func (q *sendQueue) push(message model.MessageToPlayer) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.closed {
+		return true
+	}
+
+	if len(q.messages) >= q.maxSize && !q.makeRoom() {
+		return false
+	}
+
+	q.seq++
+	message.Seq = q.seq
+	q.messages = append(q.messages, message)
+	queuedMessages.Inc()
+	q.recordDepth()
+	q.signal()
+	return true
+}
+
+// makeRoom applies the overflow policy to a full queue. It returns true if there is now room.
+func (q *sendQueue) makeRoom() bool {
+	if q.policy != DropStale {
+		return false
+	}
+
+	kept := q.messages[:0]
+	for _, message := range q.messages {
+		if message.RoundSummary == nil {
+			kept = append(kept, message)
+		}
+	}
+	dropped := len(q.messages) - len(kept)
+	q.messages = kept
+
+	queuedMessages.Sub(float64(dropped))
+	droppedMessages.Add(float64(dropped))
+	return dropped > 0
+}
+
+// pop takes the next message from the queue. If the queue is empty, ok is false, and closed says whether
+// any more messages will come.
+func (q *sendQueue) pop() (message model.MessageToPlayer, ok bool, closed bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.messages) == 0 {
+		return model.MessageToPlayer{}, false, q.closed
+	}
+
+	message = q.messages[0]
+	q.messages = q.messages[1:]
+	queuedMessages.Dec()
+	return message, true, false
+}
+
+// close stops any more messages being queued. Messages already queued can still be popped, and then the
+// close frame is sent. Only the first close frame is kept.
+func (q *sendQueue) close(closeFrame []byte) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !q.closed {
+		q.closeFrame = closeFrame
+	}
+	q.closed = true
+	q.signal()
+}
+
+// closeMessage returns the close frame given when the queue was closed
+func (q *sendQueue) closeMessage() []byte {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.closeFrame
+}
+
+// discard closes the queue and throws away anything still in it
+func (q *sendQueue) discard() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	queuedMessages.Sub(float64(len(q.messages)))
+	q.messages = nil
+	q.closed = true
+}
+
+// depth returns the number of messages waiting in the queue
+func (q *sendQueue) depth() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.messages)
+}
+
+func (q *sendQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+		// The writer has already been told there is something to do
+	}
+}
+
+func (q *sendQueue) recordDepth() {
+	depth := int64(len(q.messages))
+	for {
+		max := atomic.LoadInt64(&maxQueueDepth)
+		if depth <= max || atomic.CompareAndSwapInt64(&maxQueueDepth, max, depth) {
+			return
+		}
+	}
+}
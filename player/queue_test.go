@@ -0,0 +1,101 @@
+package player
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"testing"
+)
+
+var roundSummary = model.MessageToPlayer{RoundSummary: &model.RoundSummary{}}
+var question = model.MessageToPlayer{PresentQuestion: &model.PresentQuestion{WordToGuess: "hello"}}
+
+func TestSendQueue_DropStale(t *testing.T) {
+	q := newSendQueue(3, DropStale)
+	q.push(roundSummary)
+	q.push(question)
+	q.push(roundSummary)
+
+	// The queue is full, so the round summaries make way for the new message
+	if !q.push(question) {
+		t.Fatal("Expected room to be made by dropping stale round summaries")
+	}
+	if q.depth() != 2 {
+		t.Errorf("Got depth %d and expected 2", q.depth())
+	}
+
+	q.push(question)
+	// Now full of questions, none of which can be dropped
+	if q.push(question) {
+		t.Error("Expected the queue to overflow when there is nothing stale to drop")
+	}
+}
+
+func TestSendQueue_Disconnect(t *testing.T) {
+	q := newSendQueue(2, Disconnect)
+	q.push(roundSummary)
+	q.push(roundSummary)
+
+	if q.push(roundSummary) {
+		t.Error("Expected the queue to overflow")
+	}
+}
+
+func TestSendQueue_PopInOrder(t *testing.T) {
+	q := newSendQueue(5, DropStale)
+	q.push(question)
+	q.push(roundSummary)
+
+	first, ok, _ := q.pop()
+	if !ok || first.PresentQuestion == nil {
+		t.Errorf("Expected the question first, got %v", first)
+	}
+	second, ok, _ := q.pop()
+	if !ok || second.RoundSummary == nil {
+		t.Errorf("Expected the round summary second, got %v", second)
+	}
+	_, ok, closed := q.pop()
+	if ok || closed {
+		t.Error("Expected an empty, open queue")
+	}
+}
+
+func TestSendQueue_Close(t *testing.T) {
+	q := newSendQueue(5, DropStale)
+	q.push(question)
+	q.close([]byte("first"))
+	q.close([]byte("second"))
+
+	// Messages queued before the close are still delivered
+	_, ok, closed := q.pop()
+	if !ok || closed {
+		t.Error("Expected the queued message to be popped after close")
+	}
+	_, ok, closed = q.pop()
+	if ok || !closed {
+		t.Error("Expected the queue to be closed once empty")
+	}
+
+	// Messages pushed after the close are dropped without overflowing
+	if !q.push(question) || q.depth() != 0 {
+		t.Error("Expected messages pushed after close to be dropped")
+	}
+
+	if string(q.closeMessage()) != "first" {
+		t.Errorf("Got close message %q and expected the first one", q.closeMessage())
+	}
+}
+
+func TestSendQueue_SequenceNumbers(t *testing.T) {
+	q := newSendQueue(3, DropStale)
+	q.push(question)
+	q.push(roundSummary)
+	q.push(question)
+	// The round summary is dropped to make room, leaving a gap in the sequence
+	q.push(question)
+
+	for _, expected := range []uint64{1, 3, 4} {
+		message, _, _ := q.pop()
+		if message.Seq != expected {
+			t.Errorf("Got %d and expected %d", message.Seq, expected)
+		}
+	}
+}
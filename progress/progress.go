@@ -0,0 +1,182 @@
+// Package progress tracks how far through a long running job, such as scraping, the server is. Progress can be
+// watched in-process, or streamed to HTTP clients as server-sent events.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Progress is a snapshot of how far through the job is
+type Progress struct {
+	Done     int
+	Expected int
+	Percent  int
+	Finished bool
+}
+
+// Tracker counts the work done. Watchers are told whenever the percentage changes. It is safe to use from many
+// goroutines.
+type Tracker struct {
+	lock     sync.Mutex
+	current  Progress
+	watchers map[chan Progress]bool
+	// StreamDuration ends event streams after this long, so the server's write timeout doesn't cut them off
+	// part way through an event. EventSource clients reconnect by themselves. Zero means no limit.
+	StreamDuration time.Duration
+	closing        chan struct{}
+	closeOnce      sync.Once
+}
+
+// NewTracker creates a tracker for a job expected to take the given amount of work, some of which may already
+// be done
+func NewTracker(expected int, done int) *Tracker {
+	t := &Tracker{
+		watchers: make(map[chan Progress]bool),
+		closing:  make(chan struct{}),
+	}
+	t.current.Expected = expected
+	t.setDone(done)
+	return t
+}
+
+// Close ends every event stream, eg when the server is shutting down
+func (t *Tracker) Close() {
+	t.closeOnce.Do(func() {
+		close(t.closing)
+	})
+}
+
+// Add records more work done
+func (t *Tracker) Add(n int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	previousPercent := t.current.Percent
+	t.setDone(t.current.Done + n)
+	// Only tell the watchers if there is a change, to keep the noise down
+	if t.current.Percent != previousPercent {
+		t.notify()
+	}
+}
+
+// Finish marks the job as done, even if less work was done than expected
+func (t *Tracker) Finish() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.current.Finished = true
+	t.current.Percent = 100
+	t.notify()
+}
+
+// Current returns the progress so far
+func (t *Tracker) Current() Progress {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.current
+}
+
+// Watch returns a channel that receives the current progress, and then each change. Only the latest progress
+// is kept for a slow watcher. The channel is closed once the job has finished. Call stop when done watching.
+func (t *Tracker) Watch() (updates <-chan Progress, stop func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	watcher := make(chan Progress, 1)
+	watcher <- t.current
+	if t.current.Finished {
+		close(watcher)
+		return watcher, func() {}
+	}
+
+	t.watchers[watcher] = true
+	stop = func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		if t.watchers[watcher] {
+			delete(t.watchers, watcher)
+			close(watcher)
+		}
+	}
+	return watcher, stop
+}
+
+// ServeHTTP streams the progress as server-sent events until the job finishes, the client goes away or the
+// stream has lasted StreamDuration. Each event is the Progress as JSON.
+func (t *Tracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	updates, stop := t.Watch()
+	defer stop()
+
+	var timeout <-chan time.Time
+	if t.StreamDuration > 0 {
+		timer := time.NewTimer(t.StreamDuration)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	// Reconnect quickly when the stream ends
+	fmt.Fprint(w, "retry: 1000\n")
+
+	for {
+		select {
+		case progress, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(progress)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-timeout:
+			return
+		case <-t.closing:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (t *Tracker) setDone(done int) {
+	t.current.Done = done
+	if t.current.Expected > 0 {
+		t.current.Percent = done * 100 / t.current.Expected
+	}
+	if t.current.Percent > 100 {
+		t.current.Percent = 100
+	}
+}
+
+// notify sends the current progress to every watcher, replacing anything they haven't read yet. Watchers are
+// let go once the job has finished. The lock must be held.
+func (t *Tracker) notify() {
+	for watcher := range t.watchers {
+		select {
+		case <-watcher:
+		default:
+		}
+		watcher <- t.current
+
+		if t.current.Finished {
+			delete(t.watchers, watcher)
+			close(watcher)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package progress
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTracker_Add(t *testing.T) {
+	tracker := NewTracker(200, 50)
+	updates, stop := tracker.Watch()
+	defer stop()
+
+	if progress := <-updates; progress.Percent != 25 {
+		t.Errorf("Got %d%% and expected 25%%", progress.Percent)
+	}
+
+	// Adding a word isn't enough to change the percentage, so nobody is told
+	tracker.Add(1)
+	select {
+	case progress := <-updates:
+		t.Errorf("Got unexpected update %+v", progress)
+	default:
+	}
+
+	tracker.Add(1)
+	if progress := <-updates; progress.Percent != 26 || progress.Done != 52 {
+		t.Errorf("Got %+v and expected 52 done and 26%%", progress)
+	}
+}
+
+func TestTracker_SlowWatcherGetsLatest(t *testing.T) {
+	tracker := NewTracker(100, 0)
+	updates, stop := tracker.Watch()
+	defer stop()
+
+	for i := 0; i < 10; i++ {
+		tracker.Add(1)
+	}
+
+	if progress := <-updates; progress.Percent != 10 {
+		t.Errorf("Got %d%% and expected the latest 10%%", progress.Percent)
+	}
+}
+
+func TestTracker_Finish(t *testing.T) {
+	tracker := NewTracker(100, 0)
+	updates, stop := tracker.Watch()
+	defer stop()
+	<-updates
+
+	tracker.Finish()
+	progress, ok := <-updates
+	if !ok || !progress.Finished || progress.Percent != 100 {
+		t.Errorf("Got %+v and expected the finished progress", progress)
+	}
+	if _, ok := <-updates; ok {
+		t.Error("Expected the updates to stop once finished")
+	}
+
+	// Watching a finished job gets the final progress straight away
+	late, _ := tracker.Watch()
+	if progress := <-late; !progress.Finished {
+		t.Errorf("Got %+v and expected the finished progress", progress)
+	}
+}
+
+func TestTracker_ServeHTTP(t *testing.T) {
+	tracker := NewTracker(4, 0)
+	server := httptest.NewServer(tracker)
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Got content type %q and expected text/event-stream", contentType)
+	}
+
+	go func() {
+		for i := 0; i < 4; i++ {
+			tracker.Add(1)
+		}
+		tracker.Finish()
+	}()
+
+	// The stream ends once the job has finished
+	var last Progress
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &last)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !last.Finished || last.Done != 4 {
+		t.Errorf("Got %+v and expected the finished progress", last)
+	}
+}
+
+func TestTracker_StreamEnds(t *testing.T) {
+	// The job never finishes, but the stream ends after StreamDuration
+	tracker := NewTracker(4, 0)
+	tracker.StreamDuration = 50 * time.Millisecond
+	server := httptest.NewServer(tracker)
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil || !strings.HasPrefix(string(body), "retry: ") {
+		t.Errorf("Got %q, %v and expected the stream to end cleanly", body, err)
+	}
+}
+
+func TestTracker_Close(t *testing.T) {
+	tracker := NewTracker(4, 0)
+	server := httptest.NewServer(tracker)
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	ended := make(chan error)
+	go func() {
+		_, err := ioutil.ReadAll(response.Body)
+		ended <- err
+	}()
+
+	tracker.Close()
+	select {
+	case err := <-ended:
+		if err != nil {
+			t.Errorf("Got %v and expected the stream to end cleanly", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the stream to end when the tracker closed")
+	}
+}
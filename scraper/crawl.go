@@ -0,0 +1,126 @@
+package scraper
+
+import (
+	"github.com/gocolly/colly"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultUserAgent identifies the game to the websites it scrapes
+const DefaultUserAgent = "wordofthedaygame (+https://github.com/ksanta/wordofthedaygame)"
+
+const attemptKey = "attemptKey"
+
+// CrawlSettings controls how politely the word of the day websites are scraped
+type CrawlSettings struct {
+	// Concurrency is the max number of requests in flight at once
+	Concurrency int
+	// RequestsPerSecond caps the overall request rate. Zero means no limit.
+	RequestsPerSecond float64
+	// MaxRetries is how many times a page is retried after a 429, a 5xx or a network error
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry. It doubles for every retry after that.
+	RetryBackoff time.Duration
+	// UserAgent is sent with every request
+	UserAgent string
+	// IgnoreRobotsTxt turns off the robots.txt checks
+	IgnoreRobotsTxt bool
+	// AlreadyScraped holds the URLs scraped by an earlier, interrupted run. They won't be visited again.
+	AlreadyScraped map[string]bool
+}
+
+// DefaultCrawlSettings returns settings that are gentle on the websites being scraped
+func DefaultCrawlSettings() CrawlSettings {
+	return CrawlSettings{
+		Concurrency:       4,
+		RequestsPerSecond: 10,
+		MaxRetries:        5,
+		RetryBackoff:      time.Second,
+		UserAgent:         DefaultUserAgent,
+	}
+}
+
+// newCollector creates a collector that obeys the crawl settings
+func newCollector(settings CrawlSettings) *colly.Collector {
+	c := colly.NewCollector(colly.UserAgent(settings.UserAgent))
+	c.IgnoreRobotsTxt = settings.IgnoreRobotsTxt
+
+	concurrency := settings.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Each of the parallel slots waits this long between requests, so together they make the requested rate
+	var delay time.Duration
+	if settings.RequestsPerSecond > 0 {
+		delay = time.Duration(float64(concurrency) / settings.RequestsPerSecond * float64(time.Second))
+	}
+
+	err := c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Parallelism: concurrency,
+		Delay:       delay,
+	})
+	if err != nil {
+		log.Println("Unable to set the scrape rate limit:", err)
+	}
+
+	c.OnResponse(func(response *colly.Response) {
+		scrapedPages.WithLabelValues(pageFetched).Inc()
+	})
+
+	c.OnError(func(response *colly.Response, err error) {
+		scrapedPages.WithLabelValues(pageFailed).Inc()
+		retryWithBackoff(response, err, settings)
+	})
+
+	return c
+}
+
+// retryWithBackoff retries the failed request if the failure looks temporary, waiting a little longer each time
+func retryWithBackoff(response *colly.Response, err error, settings CrawlSettings) {
+	url := response.Request.URL.String()
+
+	if !isRetryable(response.StatusCode) {
+		log.Println("Unable to scrape", url, "-", err)
+		return
+	}
+
+	attempt, _ := response.Ctx.GetAny(attemptKey).(int)
+	if attempt >= settings.MaxRetries {
+		log.Println("Giving up on", url, "after", attempt, "retries -", err)
+		return
+	}
+	response.Ctx.Put(attemptKey, attempt+1)
+
+	wait := settings.RetryBackoff << uint(attempt)
+	if retryAfter := retryAfterHeader(response); retryAfter > wait {
+		wait = retryAfter
+	}
+	time.Sleep(wait)
+
+	// The retry runs straight away on this goroutine. If it fails too, this callback is called again.
+	_ = response.Request.Retry()
+}
+
+// isRetryable returns true for the failures that are worth another try. A status code of zero means the
+// request failed before there was a response, eg a connection reset.
+func isRetryable(statusCode int) bool {
+	return statusCode == 0 ||
+		statusCode == http.StatusTooManyRequests ||
+		statusCode >= http.StatusInternalServerError
+}
+
+// retryAfterHeader returns how long the server asked us to wait, if it said
+func retryAfterHeader(response *colly.Response) time.Duration {
+	if response.Headers == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(response.Headers.Get("Retry-After"))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testCrawlSettings doesn't hold back, so the tests run quickly
+func testCrawlSettings() CrawlSettings {
+	settings := DefaultCrawlSettings()
+	settings.RequestsPerSecond = 0
+	settings.RetryBackoff = time.Millisecond
+	return settings
+}
+
+// flakyServer fails the first few requests for each page with the given status code, then serves the fixture
+type flakyServer struct {
+	sync.Mutex
+	failures   int
+	statusCode int
+	requests   map[string]int
+	userAgents map[string]bool
+}
+
+func (f *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	f.requests[r.URL.Path]++
+	count := f.requests[r.URL.Path]
+	f.Unlock()
+
+	if r.URL.Path == "/robots.txt" {
+		http.ServeFile(w, r, filepath.Join("testdata", "robots.txt"))
+		return
+	}
+	f.Lock()
+	f.userAgents[r.UserAgent()] = true
+	f.Unlock()
+
+	if count <= f.failures {
+		w.WriteHeader(f.statusCode)
+		return
+	}
+	http.ServeFile(w, r, filepath.Join("testdata", "meriam", "noun.html"))
+}
+
+func newFlakyServer(failures int, statusCode int) (*flakyServer, *httptest.Server) {
+	flaky := &flakyServer{
+		failures:   failures,
+		statusCode: statusCode,
+		requests:   make(map[string]int),
+		userAgents: make(map[string]bool),
+	}
+	return flaky, httptest.NewServer(flaky)
+}
+
+func TestCrawl_RetriesTemporaryFailures(t *testing.T) {
+	for _, statusCode := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		flaky, server := newFlakyServer(2, statusCode)
+
+		words := collectWords(t, NewMeriamScraper(server.URL+"/word-of-the-day/", 3, testCrawlSettings()))
+		server.Close()
+
+		if len(words) != 3 {
+			t.Errorf("Status %d: got %d words and expected 3", statusCode, len(words))
+		}
+		for path, count := range flaky.requests {
+			if strings.HasPrefix(path, "/word-of-the-day/") && count != 3 {
+				t.Errorf("Status %d: got %d requests for %s and expected 3", statusCode, count, path)
+			}
+		}
+	}
+}
+
+func TestCrawl_GivesUpAfterMaxRetries(t *testing.T) {
+	flaky, server := newFlakyServer(100, http.StatusInternalServerError)
+	defer server.Close()
+
+	settings := testCrawlSettings()
+	settings.MaxRetries = 2
+	words := collectWords(t, NewMeriamScraper(server.URL+"/word-of-the-day/", 1, settings))
+
+	if len(words) != 0 {
+		t.Errorf("Got %d words and expected none", len(words))
+	}
+	for path, count := range flaky.requests {
+		if strings.HasPrefix(path, "/word-of-the-day/") && count != 3 {
+			t.Errorf("Got %d requests for %s and expected 3", count, path)
+		}
+	}
+}
+
+func TestCrawl_DoesNotRetryNotFound(t *testing.T) {
+	flaky, server := newFlakyServer(100, http.StatusNotFound)
+	defer server.Close()
+
+	collectWords(t, NewMeriamScraper(server.URL+"/word-of-the-day/", 1, testCrawlSettings()))
+
+	for path, count := range flaky.requests {
+		if strings.HasPrefix(path, "/word-of-the-day/") && count != 1 {
+			t.Errorf("Got %d requests for %s and expected 1", count, path)
+		}
+	}
+}
+
+func TestCrawl_UserAgent(t *testing.T) {
+	flaky, server := newFlakyServer(0, http.StatusOK)
+	defer server.Close()
+
+	settings := testCrawlSettings()
+	settings.UserAgent = "test-agent"
+	collectWords(t, NewMeriamScraper(server.URL+"/word-of-the-day/", 1, settings))
+
+	if len(flaky.userAgents) != 1 || !flaky.userAgents["test-agent"] {
+		t.Errorf("Got user agents %v and expected only test-agent", flaky.userAgents)
+	}
+}
+
+func TestCrawl_ObeysRobotsTxt(t *testing.T) {
+	flaky, server := newFlakyServer(0, http.StatusOK)
+	defer server.Close()
+
+	// testdata/robots.txt disallows everything under /private/
+	words := collectWords(t, NewMeriamScraper(server.URL+"/private/", 1, testCrawlSettings()))
+
+	if len(words) != 0 {
+		t.Errorf("Got %d words and expected none", len(words))
+	}
+	for path := range flaky.requests {
+		if strings.HasPrefix(path, "/private/") {
+			t.Errorf("Got a request for %s which robots.txt disallows", path)
+		}
+	}
+}
+
+func TestCrawl_SkipsAlreadyScraped(t *testing.T) {
+	flaky, server := newFlakyServer(0, http.StatusOK)
+	defer server.Close()
+
+	baseURL := server.URL + "/word-of-the-day/"
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	settings := testCrawlSettings()
+	settings.AlreadyScraped = map[string]bool{baseURL + yesterday: true}
+
+	words := collectWords(t, NewMeriamScraper(baseURL, 3, settings))
+
+	if len(words) != 2 {
+		t.Errorf("Got %d words and expected 2", len(words))
+	}
+	if flaky.requests["/word-of-the-day/"+yesterday] != 0 {
+		t.Errorf("Expected yesterday's page to be skipped")
+	}
+}
+
+func TestCrawl_CountsPages(t *testing.T) {
+	fetchedBefore := testutil.ToFloat64(scrapedPages.WithLabelValues(pageFetched))
+	failedBefore := testutil.ToFloat64(scrapedPages.WithLabelValues(pageFailed))
+
+	_, server := newFlakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+	collectWords(t, NewMeriamScraper(server.URL+"/word-of-the-day/", 3, testCrawlSettings()))
+
+	fetched := testutil.ToFloat64(scrapedPages.WithLabelValues(pageFetched)) - fetchedBefore
+	failed := testutil.ToFloat64(scrapedPages.WithLabelValues(pageFailed)) - failedBefore
+	if fetched != 3 || failed != 3 {
+		t.Errorf("Got %v fetched and %v failed and expected 3 of each", fetched, failed)
+	}
+}
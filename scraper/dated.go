@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"github.com/gocolly/colly"
+	"github.com/gocolly/colly/queue"
+	"github.com/ksanta/wordofthedaygame/model"
+	"time"
+)
+
+// Keys used to stash the parts of a word in the request context while a page is being scraped
+const wotdKey = "wotdKey"
+const wordTypeKey = "wordTypeKey"
+const definitionKey = "definitionKey"
+
+// sendScrapedWords builds a word from the request context once each page has been scraped and sends
+// it to the output channel
+func sendScrapedWords(c *colly.Collector, source string, outputChan chan model.Word) {
+	c.OnScraped(func(response *colly.Response) {
+		wordEntry := model.Word{
+			Word:       response.Ctx.Get(wotdKey),
+			WordType:   response.Ctx.Get(wordTypeKey),
+			Definition: response.Ctx.Get(definitionKey),
+			URL:        response.Request.URL.String(),
+			Source:     source,
+		}
+		outputChan <- wordEntry
+	})
+}
+
+// visitDatedPages generates one URL per day, starting from yesterday and going back limit days, and visits
+// them all with the collector. Pages that were scraped by an earlier run are skipped. It returns once every
+// page has been visited.
+func visitDatedPages(c *colly.Collector, settings CrawlSettings, limit int, urlForDate func(date time.Time) string) {
+	concurrency := settings.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	q, _ := queue.New(
+		concurrency,
+		&queue.InMemoryQueueStorage{MaxSize: 10000},
+	)
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	for i := 0; i < limit; i++ {
+		date := yesterday.AddDate(0, 0, -i)
+		url := urlForDate(date)
+		if settings.AlreadyScraped[url] {
+			continue
+		}
+		q.AddURL(url)
+	}
+
+	q.Run(c)
+}
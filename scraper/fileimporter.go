@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// FileImporter reads words from a local word list. The format is picked from the file extension, which can be
+// .csv, .json, .yaml or .yml. See model.ReadWordList for the details of each format.
+type FileImporter struct {
+	wordFile string
+	limit    int
+}
+
+// NewFileImporter returns a Scraper that imports up to limit words from a local word list
+func NewFileImporter(wordFile string, limit int) Scraper {
+	return &FileImporter{
+		wordFile: wordFile,
+		limit:    limit,
+	}
+}
+
+func (f *FileImporter) Scrape() chan model.Word {
+	outputChan := make(chan model.Word)
+
+	go func() {
+		defer close(outputChan)
+
+		file, err := os.Open(f.wordFile)
+		if err != nil {
+			log.Println("Unable to open word file:", err)
+			return
+		}
+		defer file.Close()
+
+		words, err := model.ReadWordList(file, filepath.Ext(f.wordFile))
+		if err != nil {
+			log.Println("Unable to read word file:", err)
+			return
+		}
+
+		// Words without their own source are labelled with the file they came from
+		source := FileSource + ":" + filepath.Base(f.wordFile)
+		for i, word := range words {
+			if i >= f.limit {
+				break
+			}
+			if word.Source == "" {
+				word.Source = source
+			}
+			outputChan <- word
+		}
+	}()
+
+	return outputChan
+}
@@ -0,0 +1,36 @@
+package scraper
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileImporter_Scrape(t *testing.T) {
+	for _, fileName := range []string{"jargon.csv", "jargon.json", "jargon.yaml"} {
+		words := collectWords(t, NewFileImporter(filepath.Join("testdata", "wordlists", fileName), 10))
+
+		expected := model.Words{
+			{Word: "synergy", WordType: "noun", Definition: "the benefit gained when teams work together", Source: "file:" + fileName},
+			{Word: "circle back", WordType: "verb", Definition: "to return to a topic later", Source: "file:" + fileName},
+			{Word: "bikeshed", WordType: "verb", Definition: "to argue about trivial details", URL: "https://example.com/bikeshed", Source: "team glossary"},
+		}
+
+		if len(words) != len(expected) {
+			t.Errorf("%s: got %d words and expected %d", fileName, len(words), len(expected))
+			continue
+		}
+		for i := range expected {
+			if words[i] != expected[i] {
+				t.Errorf("%s: got %v and expected %v", fileName, words[i], expected[i])
+			}
+		}
+	}
+}
+
+func TestFileImporter_Scrape_Limit(t *testing.T) {
+	words := collectWords(t, NewFileImporter(filepath.Join("testdata", "wordlists", "jargon.csv"), 2))
+	if len(words) != 2 {
+		t.Errorf("Got %d words and expected 2", len(words))
+	}
+}
@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"strings"
+	"sync"
+)
+
+type mergedScraper struct {
+	scrapers []Scraper
+}
+
+// Merge combines several scrapers into one. They all run at the same time and their words are sent to a
+// single channel. If more than one source has the same word, only the first one to arrive is kept.
+func Merge(scrapers ...Scraper) Scraper {
+	if len(scrapers) == 1 {
+		return scrapers[0]
+	}
+	return &mergedScraper{scrapers}
+}
+
+func (m *mergedScraper) Scrape() chan model.Word {
+	outputChan := make(chan model.Word)
+	mergedChan := make(chan model.Word)
+
+	// Fan in the words from every source
+	var waitGroup sync.WaitGroup
+	for _, s := range m.scrapers {
+		waitGroup.Add(1)
+		go func(wordChan chan model.Word) {
+			defer waitGroup.Done()
+			for word := range wordChan {
+				mergedChan <- word
+			}
+		}(s.Scrape())
+	}
+
+	go func() {
+		waitGroup.Wait()
+		close(mergedChan)
+	}()
+
+	// Drop the duplicates
+	go func() {
+		seen := make(map[string]bool)
+		for word := range mergedChan {
+			key := strings.ToLower(word.Word)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			outputChan <- word
+		}
+		close(outputChan)
+	}()
+
+	return outputChan
+}
@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"testing"
+)
+
+// fixedScraper is a Scraper that produces a fixed list of words
+type fixedScraper model.Words
+
+func (f fixedScraper) Scrape() chan model.Word {
+	outputChan := make(chan model.Word)
+	go func() {
+		for _, word := range f {
+			outputChan <- word
+		}
+		close(outputChan)
+	}()
+	return outputChan
+}
+
+func TestMerge(t *testing.T) {
+	first := fixedScraper{
+		{Word: "one", Source: "first"},
+		{Word: "two", Source: "first"},
+	}
+	second := fixedScraper{
+		{Word: "Two", Source: "second"},
+		{Word: "three", Source: "second"},
+	}
+
+	words := collectWords(t, Merge(first, second))
+
+	if len(words) != 3 {
+		t.Fatalf("Got %d words and expected 3: %v", len(words), words)
+	}
+	bySource := make(map[string]int)
+	for _, word := range words {
+		bySource[word.Source]++
+	}
+	if bySource["first"]+bySource["second"] != 3 || bySource["first"] == 0 || bySource["second"] == 0 {
+		t.Errorf("Expected words from both sources, got %v", words)
+	}
+}
+
+func TestNewFromName_Unknown(t *testing.T) {
+	_, err := NewFromName("nonsense", Options{})
+	if err == nil {
+		t.Error("Expected an error for an unknown source")
+	}
+}
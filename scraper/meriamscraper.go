@@ -2,23 +2,28 @@ package scraper
 
 import (
 	"github.com/gocolly/colly"
-	"github.com/gocolly/colly/queue"
 	"github.com/ksanta/wordofthedaygame/model"
 	"strings"
 	"time"
 )
 
-const wotdKey = "wotdKey"
-const wordTypeKey = "wordTypeKey"
-const definitionKey = "definitionKey"
+// MeriamBaseURL is where the Meriam word of the day pages live. Each page is found by appending a date.
+const MeriamBaseURL = "https://www.merriam-webster.com/word-of-the-day/"
 
 type MeriamScraper struct {
-	limit int
+	baseURL  string
+	limit    int
+	settings CrawlSettings
 }
 
-// NewMeriamScraper returns the Meriam implementation of the Scraper interface
-func NewMeriamScraper(limit int) Scraper {
-	return &MeriamScraper{limit}
+// NewMeriamScraper returns the Meriam implementation of the Scraper interface. Pages are requested
+// from baseURL, which is normally MeriamBaseURL but can point elsewhere (eg a test server).
+func NewMeriamScraper(baseURL string, limit int, settings CrawlSettings) Scraper {
+	return &MeriamScraper{
+		baseURL:  baseURL,
+		limit:    limit,
+		settings: settings,
+	}
 }
 
 func (m *MeriamScraper) Scrape() chan model.Word {
@@ -26,8 +31,8 @@ func (m *MeriamScraper) Scrape() chan model.Word {
 
 	go func() {
 
-		// Instantiate default collector
-		c := colly.NewCollector()
+		// Instantiate a collector that obeys the crawl settings
+		c := newCollector(m.settings)
 
 		// Scrape the word of the day
 		c.OnHTML("h1", func(element *colly.HTMLElement) {
@@ -49,31 +54,11 @@ func (m *MeriamScraper) Scrape() chan model.Word {
 			})
 		})
 
-		c.OnScraped(func(response *colly.Response) {
-			wordEntry := model.Word{
-				Word:       response.Ctx.Get(wotdKey),
-				WordType:   response.Ctx.Get(wordTypeKey),
-				Definition: response.Ctx.Get(definitionKey),
-				URL:        response.Request.URL.String(),
-			}
-			outputChan <- wordEntry
-		})
-
-		q, _ := queue.New(
-			20,
-			&queue.InMemoryQueueStorage{MaxSize: 10000},
-		)
+		sendScrapedWords(c, MeriamSource, outputChan)
 
-		// Generate URLs based on dates and visit them all
-		yesterday := time.Now().AddDate(0, 0, -1)
-		for i := 0; i < m.limit; i++ {
-			date := yesterday.AddDate(0, 0, -i)
-			formattedDate := date.Format("2006-01-02")
-			url := "https://www.merriam-webster.com/word-of-the-day/" + formattedDate
-			q.AddURL(url)
-		}
-
-		q.Run(c)
+		visitDatedPages(c, m.settings, m.limit, func(date time.Time) string {
+			return m.baseURL + date.Format("2006-01-02")
+		})
 
 		close(outputChan)
 	}()
@@ -0,0 +1,147 @@
+package scraper
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveFixture starts a test server that responds to every page under pathPrefix with the recorded fixture
+func serveFixture(t *testing.T, pathPrefix string, fixture string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, pathPrefix) {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", fixture))
+	}))
+}
+
+// collectWords runs the scraper and collects every word it produces
+func collectWords(t *testing.T, s Scraper) model.Words {
+	t.Helper()
+
+	var words model.Words
+	wordChan := s.Scrape()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case word, ok := <-wordChan:
+			if !ok {
+				return words
+			}
+			words = append(words, word)
+		case <-timeout:
+			t.Fatal("Timed out waiting for the scraper to finish")
+		}
+	}
+}
+
+func scrapeOne(t *testing.T, fixture string) model.Word {
+	t.Helper()
+	server := serveFixture(t, "/word-of-the-day/", filepath.Join("meriam", fixture))
+	defer server.Close()
+
+	words := collectWords(t, NewMeriamScraper(server.URL+"/word-of-the-day/", 1, testCrawlSettings()))
+	if len(words) != 1 {
+		t.Fatalf("Got %d words and expected 1", len(words))
+	}
+	return words[0]
+}
+
+func TestMeriamScraper_Scrape_Noun(t *testing.T) {
+	got := scrapeOne(t, "noun.html")
+	expected := model.Word{
+		Word:       "serendipity",
+		WordType:   "noun",
+		Definition: "the faculty or phenomenon of finding valuable or agreeable things not sought for",
+	}
+	if got.Word != expected.Word || got.WordType != expected.WordType || got.Definition != expected.Definition {
+		t.Errorf("Got %s and expected %s", got, expected)
+	}
+	if got.Source != MeriamSource {
+		t.Errorf("Got source %q and expected %q", got.Source, MeriamSource)
+	}
+}
+
+func TestMeriamScraper_Scrape_MultipleDefinitions(t *testing.T) {
+	got := scrapeOne(t, "multiple-definitions.html")
+	expectedDefinition := "to depart secretly and hide oneself"
+	if got.Definition != expectedDefinition {
+		t.Errorf("Got definition %q and expected %q", got.Definition, expectedDefinition)
+	}
+	if got.WordType != "verb" {
+		t.Errorf("Got word type %q and expected %q", got.WordType, "verb")
+	}
+}
+
+func TestMeriamScraper_Scrape_NoColon(t *testing.T) {
+	got := scrapeOne(t, "no-colon.html")
+	expectedDefinition := "Halcyon describes a time in the past that was idyllically happy and peaceful."
+	if got.Definition != expectedDefinition {
+		t.Errorf("Got definition %q and expected %q", got.Definition, expectedDefinition)
+	}
+}
+
+func TestMeriamScraper_Scrape_MissingWordType(t *testing.T) {
+	got := scrapeOne(t, "missing-word-type.html")
+	if got.Word != "carpe diem" {
+		t.Errorf("Got word %q and expected %q", got.Word, "carpe diem")
+	}
+	if got.WordType != "" {
+		t.Errorf("Got word type %q and expected it to be empty", got.WordType)
+	}
+}
+
+func TestMeriamScraper_Scrape_URLPerDate(t *testing.T) {
+	server := serveFixture(t, "/word-of-the-day/", filepath.Join("meriam", "noun.html"))
+	defer server.Close()
+
+	const limit = 5
+	words := collectWords(t, NewMeriamScraper(server.URL+"/word-of-the-day/", limit, testCrawlSettings()))
+	if len(words) != limit {
+		t.Fatalf("Got %d words and expected %d", len(words), limit)
+	}
+
+	urls := make(map[string]bool)
+	for _, word := range words {
+		if !strings.HasPrefix(word.URL, server.URL+"/word-of-the-day/") {
+			t.Errorf("Got URL %s which is not from the test server", word.URL)
+		}
+		urls[word.URL] = true
+	}
+	if len(urls) != limit {
+		t.Errorf("Got %d unique URLs and expected %d", len(urls), limit)
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if !urls[server.URL+"/word-of-the-day/"+yesterday] {
+		t.Errorf("Expected yesterday's page %s to be scraped", yesterday)
+	}
+}
+
+func TestCleanUpDefinition(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{": to depart secretly", "to depart secretly"},
+		{"1 : to depart secretly", "to depart secretly"},
+		{"  no colon here  ", "no colon here"},
+		{"one: two: three", "two: three"},
+		{":", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		got := cleanUpDefinition(test.raw)
+		if got != test.expected {
+			t.Errorf("cleanUpDefinition(%q): got %q and expected %q", test.raw, got, test.expected)
+		}
+	}
+}
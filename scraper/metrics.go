@@ -0,0 +1,18 @@
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Page results, used to label the pages counter
+const (
+	pageFetched = "fetched"
+	pageFailed  = "failed"
+)
+
+var scrapedPages = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "wordofthedaygame",
+	Name:      "scraper_pages_total",
+	Help:      "Number of pages requested by the scraper, by result: fetched or failed. Each retry is counted.",
+}, []string{"result"})
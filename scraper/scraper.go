@@ -1,9 +1,72 @@
 // Scrapes websites for words of the day
 package scraper
 
-import "github.com/ksanta/wordofthedaygame/model"
+import (
+	"fmt"
+	"github.com/ksanta/wordofthedaygame/model"
+	"strings"
+)
 
 type Scraper interface {
 	// Scrape will scrape a website for word definitions and send them to a channel for consumption
 	Scrape() chan model.Word
 }
+
+// Names of the sources that NewFromName knows how to create
+const (
+	MeriamSource     = "merriam-webster"
+	WordnikSource    = "wordnik"
+	WiktionarySource = "wiktionary"
+	FileSource       = "file"
+)
+
+// Options holds the settings needed by the various sources. Each source only looks at the fields it needs.
+type Options struct {
+	// Limit is the max number of words to take from each source
+	Limit int
+	// MeriamURL is the base URL of the Meriam word of the day pages
+	MeriamURL string
+	// WordnikURL is the base URL of the Wordnik word of the day pages
+	WordnikURL string
+	// WiktionaryDump is the path to a Wiktionary XML dump
+	WiktionaryDump string
+	// WordFile is the path to a local CSV, JSON or YAML word list
+	WordFile string
+	// Crawl controls how politely the word of the day websites are scraped
+	Crawl CrawlSettings
+}
+
+// NewFromName creates the source with the given name, eg "merriam-webster" or "wiktionary"
+func NewFromName(name string, options Options) (Scraper, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case MeriamSource, "merriam", "meriam":
+		return NewMeriamScraper(options.MeriamURL, options.Limit, options.Crawl), nil
+	case WordnikSource:
+		return NewWordnikScraper(options.WordnikURL, options.Limit, options.Crawl), nil
+	case WiktionarySource:
+		if options.WiktionaryDump == "" {
+			return nil, fmt.Errorf("source %q needs the path to a Wiktionary dump", name)
+		}
+		return NewWiktionaryImporter(options.WiktionaryDump, options.Limit), nil
+	case FileSource:
+		if options.WordFile == "" {
+			return nil, fmt.Errorf("source %q needs the path to a word file", name)
+		}
+		return NewFileImporter(options.WordFile, options.Limit), nil
+	default:
+		return nil, fmt.Errorf("unknown word source %q", name)
+	}
+}
+
+// NewFromNames creates every named source and merges them into a single Scraper
+func NewFromNames(names []string, options Options) (Scraper, error) {
+	scrapers := make([]Scraper, 0, len(names))
+	for _, name := range names {
+		s, err := NewFromName(name, options)
+		if err != nil {
+			return nil, err
+		}
+		scrapers = append(scrapers, s)
+	}
+	return Merge(scrapers...), nil
+}
@@ -0,0 +1,207 @@
+package scraper
+
+import (
+	"encoding/xml"
+	"github.com/ksanta/wordofthedaygame/model"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// WiktionaryURL is the base URL of the Wiktionary entries, used to link each imported word back to its page
+const WiktionaryURL = "https://en.wiktionary.org/wiki/"
+
+// The Wiktionary section headings that hold definitions, mapped to the word types used by the other sources
+var wiktionaryWordTypes = map[string]string{
+	"noun":         "noun",
+	"proper noun":  "noun",
+	"verb":         "verb",
+	"adjective":    "adjective",
+	"adverb":       "adverb",
+	"preposition":  "preposition",
+	"conjunction":  "conjunction",
+	"interjection": "interjection",
+	"pronoun":      "pronoun",
+	"phrase":       "phrase",
+	"idiom":        "idiom",
+}
+
+// WiktionaryImporter reads words from a Wiktionary XML dump, such as enwiktionary-latest-pages-articles.xml,
+// which can be downloaded from https://dumps.wikimedia.org/enwiktionary/
+type WiktionaryImporter struct {
+	dumpFile string
+	limit    int
+}
+
+// wiktionaryPage is the part of each <page> element in the dump that the importer needs
+type wiktionaryPage struct {
+	Title     string `xml:"title"`
+	Namespace int    `xml:"ns"`
+	Text      string `xml:"revision>text"`
+}
+
+// NewWiktionaryImporter returns a Scraper that imports up to limit words from a Wiktionary XML dump
+func NewWiktionaryImporter(dumpFile string, limit int) Scraper {
+	return &WiktionaryImporter{
+		dumpFile: dumpFile,
+		limit:    limit,
+	}
+}
+
+func (w *WiktionaryImporter) Scrape() chan model.Word {
+	outputChan := make(chan model.Word)
+
+	go func() {
+		defer close(outputChan)
+
+		file, err := os.Open(w.dumpFile)
+		if err != nil {
+			log.Println("Unable to open Wiktionary dump:", err)
+			return
+		}
+		defer file.Close()
+
+		err = readWiktionaryDump(file, w.limit, outputChan)
+		if err != nil {
+			log.Println("Unable to read Wiktionary dump:", err)
+		}
+	}()
+
+	return outputChan
+}
+
+// readWiktionaryDump streams the pages from the dump, so the whole file never needs to be held in memory
+func readWiktionaryDump(reader io.Reader, limit int, outputChan chan model.Word) error {
+	decoder := xml.NewDecoder(reader)
+	count := 0
+
+	for count < limit {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "page" {
+			continue
+		}
+
+		var page wiktionaryPage
+		err = decoder.DecodeElement(&page, &start)
+		if err != nil {
+			return err
+		}
+
+		// Namespace 0 holds the dictionary entries. The rest are talk pages, templates and so on.
+		if page.Namespace != 0 || strings.Contains(page.Title, ":") {
+			continue
+		}
+
+		wordType, definition, found := parseWiktionaryEntry(page.Text)
+		if !found {
+			continue
+		}
+
+		outputChan <- model.Word{
+			Word:       page.Title,
+			WordType:   wordType,
+			Definition: definition,
+			URL:        WiktionaryURL + strings.ReplaceAll(page.Title, " ", "_"),
+			Source:     WiktionarySource,
+		}
+		count++
+	}
+
+	return nil
+}
+
+// parseWiktionaryEntry finds the first definition in the English section of the page's wikitext
+func parseWiktionaryEntry(wikitext string) (wordType string, definition string, found bool) {
+	inEnglish := false
+
+	for _, line := range strings.Split(wikitext, "\n") {
+		line = strings.TrimSpace(line)
+
+		// Language sections use level 2 headings, eg ==English==
+		if strings.HasPrefix(line, "==") && !strings.HasPrefix(line, "===") {
+			inEnglish = strings.Trim(line, "= ") == "English"
+			wordType = ""
+			continue
+		}
+		if !inEnglish {
+			continue
+		}
+
+		// Parts of speech use level 3 or 4 headings, eg ===Noun===
+		if strings.HasPrefix(line, "===") {
+			wordType = wiktionaryWordTypes[strings.ToLower(strings.Trim(line, "= "))]
+			continue
+		}
+
+		// Definitions are numbered list items. "#:" and "#*" are examples and quotations.
+		if wordType != "" && strings.HasPrefix(line, "# ") {
+			definition = cleanUpWikitext(line[2:])
+			if definition != "" {
+				return wordType, definition, true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+var (
+	htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	refPattern         = regexp.MustCompile(`(?s)<ref[^>]*?(/>|>.*?</ref>)`)
+	htmlTagPattern     = regexp.MustCompile(`<[^>]+>`)
+	templatePattern    = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+	linkPattern        = regexp.MustCompile(`\[\[(?:[^\[\]|]*\|)?([^\[\]|]*)\]\]`)
+	whitespacePattern  = regexp.MustCompile(`\s+`)
+)
+
+// Templates that wrap a word that should be kept in the definition. All other templates, such as
+// labels like {{lb|en|informal}}, are dropped.
+var keptTemplates = map[string]bool{
+	"l":       true,
+	"m":       true,
+	"w":       true,
+	"gloss":   true,
+	"vern":    true,
+	"taxlink": true,
+}
+
+// cleanUpWikitext strips the wiki markup from a definition, leaving the plain text
+func cleanUpWikitext(text string) string {
+	text = htmlCommentPattern.ReplaceAllString(text, "")
+	text = refPattern.ReplaceAllString(text, "")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+
+	// Templates can be nested, so keep replacing the innermost ones until there are none left
+	for templatePattern.MatchString(text) {
+		text = templatePattern.ReplaceAllStringFunc(text, func(template string) string {
+			parts := strings.Split(template[2:len(template)-2], "|")
+			if !keptTemplates[strings.TrimSpace(parts[0])] || len(parts) < 2 {
+				return ""
+			}
+			// The word to keep is the last positional parameter
+			for i := len(parts) - 1; i > 0; i-- {
+				if !strings.Contains(parts[i], "=") {
+					return parts[i]
+				}
+			}
+			return ""
+		})
+	}
+
+	text = linkPattern.ReplaceAllString(text, "$1")
+	text = strings.ReplaceAll(text, "'''", "")
+	text = strings.ReplaceAll(text, "''", "")
+	text = whitespacePattern.ReplaceAllString(text, " ")
+	text = strings.TrimSpace(text)
+	return strings.TrimLeft(text, ",;: ")
+}
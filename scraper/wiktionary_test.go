@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"github.com/ksanta/wordofthedaygame/model"
+	"path/filepath"
+	"testing"
+)
+
+func TestWiktionaryImporter_Scrape(t *testing.T) {
+	words := collectWords(t, NewWiktionaryImporter(filepath.Join("testdata", "wiktionary", "dump.xml"), 10))
+
+	expected := model.Words{
+		{
+			Word:       "serendipity",
+			WordType:   "noun",
+			Definition: "An unsought, unintended, and unexpected but fortunate discovery and/or learning experience that happens by accident.",
+			URL:        WiktionaryURL + "serendipity",
+			Source:     WiktionarySource,
+		},
+		{
+			Word:       "gobbledygook",
+			WordType:   "noun",
+			Definition: "Language that is meaningless or is made unintelligible by excessive use of jargon.",
+			URL:        WiktionaryURL + "gobbledygook",
+			Source:     WiktionarySource,
+		},
+		{
+			Word:       "quickly",
+			WordType:   "adverb",
+			Definition: "Rapidly; with speed.",
+			URL:        WiktionaryURL + "quickly",
+			Source:     WiktionarySource,
+		},
+	}
+
+	if len(words) != len(expected) {
+		t.Fatalf("Got %d words and expected %d: %v", len(words), len(expected), words)
+	}
+	for i := range expected {
+		if words[i] != expected[i] {
+			t.Errorf("Got %v and expected %v", words[i], expected[i])
+		}
+	}
+}
+
+func TestWiktionaryImporter_Scrape_Limit(t *testing.T) {
+	words := collectWords(t, NewWiktionaryImporter(filepath.Join("testdata", "wiktionary", "dump.xml"), 1))
+	if len(words) != 1 {
+		t.Errorf("Got %d words and expected 1", len(words))
+	}
+}
+
+func TestCleanUpWikitext(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"[[speak|Talk]] [[loudly]]", "Talk loudly"},
+		{"{{lb|en|slang}} a {{l|en|thing}}", "a thing"},
+		{"{{lb|en|{{w|British}} English}} nested", "nested"},
+		{"'''bold''' and ''italic''", "bold and italic"},
+		{"text<ref>citation</ref> here<!-- note -->", "text here"},
+		{"{{lb|en|obsolete}}", ""},
+	}
+
+	for _, test := range tests {
+		got := cleanUpWikitext(test.raw)
+		if got != test.expected {
+			t.Errorf("cleanUpWikitext(%q): got %q and expected %q", test.raw, got, test.expected)
+		}
+	}
+}
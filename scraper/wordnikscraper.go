@@ -0,0 +1,80 @@
+package scraper
+
+import (
+	"github.com/gocolly/colly"
+	"github.com/ksanta/wordofthedaygame/model"
+	"strings"
+	"time"
+)
+
+// WordnikBaseURL is where the Wordnik word of the day pages live. Each page is found by appending a date.
+const WordnikBaseURL = "https://www.wordnik.com/word-of-the-day/"
+
+// Wordnik abbreviates the part of speech, so these map back to the word types used by the other sources
+var wordnikWordTypes = map[string]string{
+	"n.":              "noun",
+	"v.":              "verb",
+	"transitive v.":   "verb",
+	"intransitive v.": "verb",
+	"adj.":            "adjective",
+	"adv.":            "adverb",
+	"prep.":           "preposition",
+	"conj.":           "conjunction",
+	"interj.":         "interjection",
+	"pron.":           "pronoun",
+}
+
+type WordnikScraper struct {
+	baseURL  string
+	limit    int
+	settings CrawlSettings
+}
+
+// NewWordnikScraper returns the Wordnik implementation of the Scraper interface. Pages are requested
+// from baseURL, which is normally WordnikBaseURL.
+func NewWordnikScraper(baseURL string, limit int, settings CrawlSettings) Scraper {
+	return &WordnikScraper{
+		baseURL:  baseURL,
+		limit:    limit,
+		settings: settings,
+	}
+}
+
+func (w *WordnikScraper) Scrape() chan model.Word {
+	outputChan := make(chan model.Word)
+
+	go func() {
+		c := newCollector(w.settings)
+
+		// Scrape the word of the day
+		c.OnHTML("div.word_of_the_day h1", func(element *colly.HTMLElement) {
+			element.Request.Ctx.Put(wotdKey, strings.TrimSpace(element.Text))
+		})
+
+		// The first definition holds both the word type and the definition
+		c.OnHTML("div.guts", func(element *colly.HTMLElement) {
+			element.ForEachWithBreak("li", func(i int, element *colly.HTMLElement) bool {
+				abbreviation := strings.TrimSpace(element.ChildText("abbr"))
+				wordType := wordnikWordTypes[abbreviation]
+				if wordType == "" {
+					wordType = strings.TrimSuffix(abbreviation, ".")
+				}
+				definition := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(element.Text), abbreviation))
+
+				element.Request.Ctx.Put(wordTypeKey, wordType)
+				element.Request.Ctx.Put(definitionKey, definition)
+				return false
+			})
+		})
+
+		sendScrapedWords(c, WordnikSource, outputChan)
+
+		visitDatedPages(c, w.settings, w.limit, func(date time.Time) string {
+			return w.baseURL + date.Format("2006/01/02")
+		})
+
+		close(outputChan)
+	}()
+
+	return outputChan
+}
@@ -0,0 +1,38 @@
+package scraper
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWordnikScraper_Scrape(t *testing.T) {
+	server := serveFixture(t, "/word-of-the-day/", filepath.Join("wordnik", "wotd.html"))
+	defer server.Close()
+
+	words := collectWords(t, NewWordnikScraper(server.URL+"/word-of-the-day/", 1, testCrawlSettings()))
+	if len(words) != 1 {
+		t.Fatalf("Got %d words and expected 1", len(words))
+	}
+
+	got := words[0]
+	if got.Word != "nidus" {
+		t.Errorf("Got word %q and expected %q", got.Word, "nidus")
+	}
+	if got.WordType != "noun" {
+		t.Errorf("Got word type %q and expected %q", got.WordType, "noun")
+	}
+	expectedDefinition := "A nest or breeding place, especially a nest in which spiders, insects, or snails deposit their eggs."
+	if got.Definition != expectedDefinition {
+		t.Errorf("Got definition %q and expected %q", got.Definition, expectedDefinition)
+	}
+	if got.Source != WordnikSource {
+		t.Errorf("Got source %q and expected %q", got.Source, WordnikSource)
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006/01/02")
+	if !strings.HasSuffix(got.URL, "/word-of-the-day/"+yesterday) {
+		t.Errorf("Got URL %s and expected it to end with yesterday's date %s", got.URL, yesterday)
+	}
+}
@@ -0,0 +1,42 @@
+// Package security protects the server's endpoints from other websites and from clients making too many requests,
+// and helps keep the traffic to it private with TLS
+package security
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OriginChecker decides which websites' pages can connect to the game and start it. Pages served by the server
+// itself can always connect.
+type OriginChecker struct {
+	allowAll bool
+	allowed  map[string]bool
+}
+
+// NewOriginChecker allows pages from the given origins, eg "https://example.com". "*" allows every origin.
+func NewOriginChecker(origins []string) *OriginChecker {
+	checker := &OriginChecker{allowed: make(map[string]bool)}
+	for _, origin := range origins {
+		if origin == "*" {
+			checker.allowAll = true
+		}
+		checker.allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+	return checker
+}
+
+// Check returns true if the request can go ahead. Requests without an Origin header don't come from a browser,
+// so there is no page to check, and they are allowed.
+func (c *OriginChecker) Check(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || c.allowAll || c.allowed[strings.ToLower(origin)] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
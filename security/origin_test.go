@@ -0,0 +1,37 @@
+package security
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOriginChecker_Check(t *testing.T) {
+	checker := NewOriginChecker([]string{"https://Stallion.example.com/"})
+
+	tests := []struct {
+		origin   string
+		expected bool
+	}{
+		{"", true},
+		{"http://localhost:8080", true},
+		{"https://stallion.example.com", true},
+		{"https://evil.example.com", false},
+		{"http://localhost:9090", false},
+		{"://", false},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest("GET", "http://localhost:8080/game", nil)
+		if test.origin != "" {
+			request.Header.Set("Origin", test.origin)
+		}
+		if got := checker.Check(request); got != test.expected {
+			t.Errorf("%q: Got %v and expected %v", test.origin, got, test.expected)
+		}
+	}
+
+	request := httptest.NewRequest("GET", "http://localhost:8080/game", nil)
+	request.Header.Set("Origin", "https://evil.example.com")
+	if !NewOriginChecker([]string{"*"}).Check(request) {
+		t.Errorf("Got false and expected * to allow every origin")
+	}
+}
@@ -0,0 +1,104 @@
+package security
+
+import (
+	"golang.org/x/time/rate"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits how many requests each IP address can make. Each address can make burst requests at once,
+// and then perMinute requests a minute.
+type RateLimiter struct {
+	lock      sync.Mutex
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*client
+	lastSweep time.Time
+	// now returns the current time. Tests replace it to move time on.
+	now func() time.Time
+}
+
+// client is the limiter for one IP address, and when it was last used
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// sweepInterval is how often addresses that have gone quiet are forgotten
+const sweepInterval = time.Minute
+
+// NewRateLimiter creates a limiter that allows perMinute requests a minute from each IP address, in bursts of
+// up to burst
+func NewRateLimiter(perMinute int, burst int) *RateLimiter {
+	return &RateLimiter{
+		limit:   rate.Limit(float64(perMinute) / 60),
+		burst:   burst,
+		clients: make(map[string]*client),
+		now:     time.Now,
+	}
+}
+
+// Allow returns true if the IP address can make a request now. Otherwise it returns how long until it can.
+func (l *RateLimiter) Allow(ip string) (bool, time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = c
+	}
+	c.lastSeen = now
+
+	reservation := c.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		// The request isn't going ahead, so it mustn't use up a later request's turn
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// sweep forgets addresses that haven't made a request for long enough to have their whole burst back
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	refill := time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second))
+	for ip, c := range l.clients {
+		if now.Sub(c.lastSeen) > refill {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+// Limit wraps a handler, turning away requests from addresses that have made too many with a 429 and a
+// Retry-After header
+func (l *RateLimiter) Limit(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, retryAfter := l.Allow(clientIP(r))
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, "Too many requests. Try again soon.", http.StatusTooManyRequests)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the address the request came from. Headers like X-Forwarded-For are ignored, since any
+// client can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,59 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(60, 2)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := limiter.Allow("10.0.0.1"); !allowed {
+			t.Errorf("Request %d: Got refused and expected the burst to be allowed", i+1)
+		}
+	}
+	allowed, retryAfter := limiter.Allow("10.0.0.1")
+	if allowed || retryAfter != time.Second {
+		t.Errorf("Got %v, %v and expected to wait %v", allowed, retryAfter, time.Second)
+	}
+
+	// Other addresses have their own limit
+	if allowed, _ := limiter.Allow("10.0.0.2"); !allowed {
+		t.Errorf("Got refused and expected another address to be allowed")
+	}
+
+	// Refused requests don't count, so one request is allowed a second later
+	now = now.Add(time.Second)
+	if allowed, _ := limiter.Allow("10.0.0.1"); !allowed {
+		t.Errorf("Got refused and expected a request to be allowed after waiting")
+	}
+
+	// Addresses that have gone quiet are forgotten
+	now = now.Add(time.Hour)
+	limiter.Allow("10.0.0.3")
+	if len(limiter.clients) != 1 {
+		t.Errorf("Got %d addresses and expected 1", len(limiter.clients))
+	}
+}
+
+func TestRateLimiter_Limit(t *testing.T) {
+	handler := NewRateLimiter(1, 1).Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	statuses := make([]int, 2)
+	for i := range statuses {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/start", nil))
+		statuses[i] = recorder.Code
+		if i == 1 && recorder.Header().Get("Retry-After") != "60" {
+			t.Errorf("Got Retry-After %q and expected 60", recorder.Header().Get("Retry-After"))
+		}
+	}
+	if statuses[0] != http.StatusOK || statuses[1] != http.StatusTooManyRequests {
+		t.Errorf("Got %v and expected %d then %d", statuses, http.StatusOK, http.StatusTooManyRequests)
+	}
+}
@@ -0,0 +1,57 @@
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestSelfSignedCertificate(t *testing.T) {
+	certificate, certPEM, err := SelfSignedCertificate([]string{"localhost", "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	server.StartTLS()
+	defer server.Close()
+
+	// A client that trusts the certificate can connect
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(caFile, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	pool, err := CertPool(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Got %v and expected the certificate to be trusted", err)
+	}
+	response.Body.Close()
+
+	// Any other client can't
+	_, err = http.Get(server.URL)
+	var unknownAuthority x509.UnknownAuthorityError
+	if !errors.As(err, &unknownAuthority) {
+		t.Errorf("Got %v and expected an unknown authority", err)
+	}
+}
+
+func TestCertPool_NoCertificates(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "empty.pem")
+	if err := ioutil.WriteFile(file, []byte("nothing here"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CertPool(file); !errors.Is(err, ErrNoCertificates) {
+		t.Errorf("Got %v and expected %v", err, ErrNoCertificates)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"github.com/ksanta/wordofthedaygame/config"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cfg holds the server settings. They come from the defaults, then the config file, then environment variables,
+// then any flags given on the command line.
+var cfg = config.Default()
+
+var configFile = flag.String("config", "", "YAML, JSON or TOML config file. Defaults to $"+config.EnvPrefix+"CONFIG.")
+
+func init() {
+	flag.StringVar(&cfg.Cache.Type, "cacheType", cfg.Cache.Type, "Must be 'file' for now")
+	flag.StringVar(&cfg.Cache.File, "cache", cfg.Cache.File, "Cache file name")
+	flag.IntVar(&cfg.Cache.Limit, "cacheLimit", cfg.Cache.Limit, "The max number of words to cache from each source")
+	flag.Var((*listFlag)(&cfg.Scraper.Sources), "sources", "Comma separated word sources: merriam-webster, wordnik, wiktionary, file")
+	flag.StringVar(&cfg.Scraper.WiktionaryDump, "wiktionaryDump", cfg.Scraper.WiktionaryDump, "Path to a Wiktionary XML dump, for the wiktionary source")
+	flag.StringVar(&cfg.Scraper.WordFile, "wordFile", cfg.Scraper.WordFile, "Path to a CSV, JSON or YAML word list, for the file source")
+	flag.IntVar(&cfg.Scraper.Concurrency, "scrapeConcurrency", cfg.Scraper.Concurrency, "Max number of scrape requests in flight at once")
+	flag.Float64Var(&cfg.Scraper.RequestsPerSecond, "scrapeRate", cfg.Scraper.RequestsPerSecond, "Max scrape requests per second, or 0 for no limit")
+	flag.IntVar(&cfg.Scraper.MaxRetries, "scrapeRetries", cfg.Scraper.MaxRetries, "Number of retries for pages that fail with a 429, 5xx or network error")
+	flag.StringVar(&cfg.Scraper.UserAgent, "userAgent", cfg.Scraper.UserAgent, "User-Agent sent when scraping")
+	flag.BoolVar(&cfg.Scraper.IgnoreRobotsTxt, "ignoreRobots", cfg.Scraper.IgnoreRobotsTxt, "Scrape pages even if robots.txt disallows it")
+	flag.IntVar(&cfg.Scraper.MinWordsPerType, "minWordsPerType", cfg.Scraper.MinWordsPerType, "Players can join once a word type has this many words")
+	flag.IntVar(&cfg.Rules.TargetScore, "targetScore", cfg.Rules.TargetScore, "Player wins when target score is reached")
+	flag.IntVar(&cfg.Rules.OptionsPerQuestion, "optionsPerQuestion", cfg.Rules.OptionsPerQuestion, "Number of options per question")
+	flag.DurationVar(&cfg.Rules.QuestionDuration.Duration, "questionDuration", cfg.Rules.QuestionDuration.Duration, "Time allowed to answer each question")
+	flag.IntVar(&cfg.Rules.MaxPlayers, "maxPlayers", cfg.Rules.MaxPlayers, "The game starts by itself when this many players have joined")
+	flag.DurationVar(&cfg.Rules.CountdownDuration.Duration, "countdown", cfg.Rules.CountdownDuration.Duration, "Time between starting the game and the first question")
+	flag.DurationVar(&cfg.Rules.RevealDuration.Duration, "revealDuration", cfg.Rules.RevealDuration.Duration, "Time the results of each round are shown")
+	flag.IntVar(&cfg.Rules.CorrectPoints, "correctPoints", cfg.Rules.CorrectPoints, "Points for a correct answer")
+	flag.IntVar(&cfg.Rules.SpeedPoints, "speedPoints", cfg.Rules.SpeedPoints, "Points for answering straight away, falling to zero as time runs out")
+	flag.Var(optionalBoolFlag{&cfg.Rules.MixedTypes}, "mixedTypes", "Let the options in a question have different word types")
+	flag.StringVar(&cfg.HTTP.Addr, "addr", cfg.HTTP.Addr, "http service address")
+	flag.StringVar(&cfg.HTTP.TLSCertFile, "tlsCert", cfg.HTTP.TLSCertFile, "PEM certificate file, to serve HTTPS")
+	flag.StringVar(&cfg.HTTP.TLSKeyFile, "tlsKey", cfg.HTTP.TLSKeyFile, "PEM private key file for -tlsCert")
+	flag.BoolVar(&cfg.HTTP.TLSSelfSigned, "tlsSelfSigned", cfg.HTTP.TLSSelfSigned, "Serve HTTPS with a self-signed certificate, saved to -tlsCert if given")
+	flag.StringVar(&cfg.HTTP.StaticDir, "staticDir", cfg.HTTP.StaticDir, "Serve the web client from this directory instead of the built-in copy, eg static")
+	flag.DurationVar(&cfg.HTTP.ShutdownGrace.Duration, "shutdownGrace", cfg.HTTP.ShutdownGrace.Duration, "On shutdown, how long games in progress have to finish")
+	flag.StringVar(&cfg.Decks.Dir, "deckDir", cfg.Decks.Dir, "Directory where uploaded decks are saved")
+	flag.Var((*listFlag)(&cfg.Decks.Default), "decks", "Comma separated decks used by rooms that don't pick their own")
+	flag.DurationVar(&cfg.Connection.PongWait.Duration, "pongWait", cfg.Connection.PongWait.Duration, "Disconnect players that haven't responded to a ping for this long")
+	flag.DurationVar(&cfg.Connection.WriteWait.Duration, "writeWait", cfg.Connection.WriteWait.Duration, "Disconnect players when sending them a message takes this long")
+	flag.Int64Var(&cfg.Connection.MaxMessageSize, "maxMessageSize", cfg.Connection.MaxMessageSize, "Largest message in bytes that a player may send")
+	flag.IntVar(&cfg.Connection.SendQueueSize, "sendQueueSize", cfg.Connection.SendQueueSize, "Messages that can wait to be sent to a slow player")
+	flag.StringVar(&cfg.Connection.OverflowPolicy, "overflowPolicy", cfg.Connection.OverflowPolicy, "What to do when a player's send queue is full: 'drop-stale' or 'disconnect'")
+	flag.StringVar(&cfg.Accounts.File, "accountsFile", cfg.Accounts.File, "File where player accounts are saved")
+	flag.IntVar(&cfg.Names.MaxLength, "maxNameLength", cfg.Names.MaxLength, "Longest name a player can pick")
+	flag.StringVar(&cfg.Icons.AvatarDir, "avatarDir", cfg.Icons.AvatarDir, "Directory where uploaded avatars are saved")
+	flag.IntVar(&cfg.Icons.MaxAvatars, "maxAvatars", cfg.Icons.MaxAvatars, "Most avatars that can be uploaded")
+	flag.Var((*listFlag)(&cfg.Security.AllowedOrigins), "allowedOrigins", "Comma separated origins of other websites whose pages can join and start games, or * for any")
+	flag.StringVar(&cfg.Security.AdminToken, "adminToken", cfg.Security.AdminToken, "Token that can start the game in any room. Prefer $"+config.EnvPrefix+"SECURITY_ADMIN_TOKEN, so it isn't seen in the process list.")
+	flag.IntVar(&cfg.Security.RequestsPerMinute, "requestsPerMinute", cfg.Security.RequestsPerMinute, "Times a minute each IP address can join or start a game, upload an avatar or log in")
+	flag.StringVar(&cfg.Names.BlockedWordsFile, "blockedWords", cfg.Names.BlockedWordsFile, "File of words that can't be used in names, one per line")
+}
+
+// loadConfig applies the config file and environment variables to the settings. Flags given on the command line
+// win over both, so they are put back afterwards. Call it after flag.Parse.
+func loadConfig() error {
+	givenFlags := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		givenFlags[f.Name] = f.Value.String()
+	})
+
+	fileName := *configFile
+	if fileName == "" {
+		fileName = os.Getenv(config.EnvPrefix + "CONFIG")
+	}
+	if fileName != "" {
+		err := config.LoadFile(&cfg, fileName)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := config.ApplyEnv(&cfg, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range givenFlags {
+		err := flag.Set(name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cfg.Validate()
+}
+
+// listFlag is a flag holding a comma separated list
+type listFlag []string
+
+func (l *listFlag) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = splitList(value)
+	return nil
+}
+
+// optionalBoolFlag is a bool flag for a setting that is left unset unless it is given
+type optionalBoolFlag struct {
+	value **bool
+}
+
+func (f optionalBoolFlag) String() string {
+	if f.value == nil || *f.value == nil {
+		return "false"
+	}
+	return strconv.FormatBool(**f.value)
+}
+
+func (f optionalBoolFlag) Set(value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*f.value = &b
+	return nil
+}
+
+func (f optionalBoolFlag) IsBoolFlag() bool {
+	return true
+}
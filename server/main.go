@@ -1,151 +1,626 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"github.com/gorilla/websocket"
+	"github.com/ksanta/wordofthedaygame/account"
+	"github.com/ksanta/wordofthedaygame/api"
 	"github.com/ksanta/wordofthedaygame/cache"
+	"github.com/ksanta/wordofthedaygame/codec"
+	"github.com/ksanta/wordofthedaygame/deck"
 	"github.com/ksanta/wordofthedaygame/game"
+	"github.com/ksanta/wordofthedaygame/icon"
+	"github.com/ksanta/wordofthedaygame/lobby"
 	"github.com/ksanta/wordofthedaygame/model"
+	"github.com/ksanta/wordofthedaygame/names"
 	"github.com/ksanta/wordofthedaygame/player"
+	"github.com/ksanta/wordofthedaygame/progress"
 	"github.com/ksanta/wordofthedaygame/scraper"
+	"github.com/ksanta/wordofthedaygame/security"
+	"github.com/ksanta/wordofthedaygame/static"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
-var (
-	cacheType          = flag.String("cacheType", "file", "Must be 'file' for now")
-	cacheFile          = flag.String("cache", "words.cache", "Cache file name")
-	cacheLimit         = flag.Int("cacheLimit", 3000, "The max number of words to cache")
-	targetScore        = flag.Int("targetScore", 500, "Player wins when target score is reached")
-	optionsPerQuestion = flag.Int("optionsPerQuestion", 3, "Number of options per question")
-	addr               = flag.String("addr", ":8080", "http service address")
-)
+var deckStore *deck.Store
+var theLobby *lobby.Lobby
+var scrapeProgress *progress.Tracker
+var accounts *account.Handler
+var nameChecker *names.Checker
+var icons = icon.NewCatalogue(icon.Horses...)
+
+// publishInterval is how often the games are given the words scraped so far
+const publishInterval = 10 * time.Second
+
+// ready is closed once the words are loaded and players can join
+var ready = make(chan struct{})
+
+// connections counts the open websocket connections, so shutdown can wait for them to close
+var connections sync.WaitGroup
+
+// closingConnections is closed on shutdown once every game has stopped. Connections that never joined a
+// game are closed then.
+var closingConnections = make(chan struct{})
 
-var theGame *game.Game
+// origins are the websites whose pages can join and start games
+var origins *security.OriginChecker
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		// Accept requests from any Origin
-		origin := r.Header.Get("Origin")
-		log.Println("Connection referred from origin", origin)
-		return true
-	},
+	// Clients pick how messages are encoded with the subprotocol. Those that don't pick get JSON.
+	Subprotocols: codec.Subprotocols(),
+	CheckOrigin:  checkOrigin,
 }
 
 func main() {
 	flag.Parse()
 	log.SetFlags(0)
 
-	initialiseTheGame()
+	if err := loadConfig(); err != nil {
+		log.Fatal(err)
+	}
 
-	fs := http.FileServer(http.Dir("./static"))
-	http.Handle("/", fs)
-	http.HandleFunc("/game", handleNewPlayer)
-	http.HandleFunc("/start", handleStartGame)
-	log.Println("Listening on", *addr)
-	log.Fatal(http.ListenAndServe(*addr, nil))
+	deckStore = deck.NewStore(cfg.Decks.Dir)
+	accountStore, err := account.NewStore(cfg.Accounts.File)
+	if err != nil {
+		log.Fatal(err)
+	}
+	accounts = account.NewHandler(accountStore, cfg.Accounts.SessionDuration.Duration, cfg.Accounts.TokenDuration.Duration)
+	// Session cookies are only sent back over HTTPS when the server serves it, so they can't be seen in transit
+	accounts.Secure = cfg.HTTP.TLS()
+	nameChecker, err = loadNameChecker()
+	if err != nil {
+		log.Fatal(err)
+	}
+	icons.MaxAvatars = cfg.Icons.MaxAvatars
+	err = icons.LoadAvatars(cfg.Icons.AvatarDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	origins = security.NewOriginChecker(cfg.Security.AllowedOrigins)
+	limiter := security.NewRateLimiter(cfg.Security.RequestsPerMinute, cfg.Security.RequestBurst)
+	iconAPI := guardWrites(limiter, icon.NewHandler(icons, cfg.Icons.MaxUploadBytes))
+	scrapeProgress = progress.NewTracker(cfg.Cache.Limit*len(cfg.Scraper.Sources), 0)
+	initialiseTheLobby()
+
+	gameAPI := api.NewHandler(theLobby)
+	// Event streams end before the write timeout would cut them off, and clients reconnect
+	gameAPI.StreamDuration = cfg.HTTP.WriteTimeout.Duration * 9 / 10
+	scrapeProgress.StreamDuration = gameAPI.StreamDuration
+
+	http.Handle("/", http.FileServer(webClient()))
+	http.HandleFunc("/healthz", handleHealthz)
+	http.HandleFunc("/readyz", handleReadyz)
+	http.Handle("/scrape/progress", scrapeProgress)
+	http.Handle("/game", limiter.Limit(whenReady(http.HandlerFunc(handleNewPlayer))))
+	http.Handle("/start", limiter.Limit(whenReady(http.HandlerFunc(handleStartGame))))
+	http.Handle("/decks/", whenReady(http.StripPrefix("/decks", deck.NewHandler(deckStore, cfg.Rules.OptionsPerQuestion))))
+	http.Handle("/account/", guardWrites(limiter, http.StripPrefix("/account", accounts)))
+	http.Handle("/api/icons", http.StripPrefix("/api/icons", iconAPI))
+	http.Handle("/api/icons/", http.StripPrefix("/api/icons", iconAPI))
+	http.Handle("/api/games", http.StripPrefix("/api/games", gameAPI))
+	http.Handle("/api/games/", http.StripPrefix("/api/games", gameAPI))
+	http.Handle("/metrics", promhttp.Handler())
+
+	// The server is up while the words are loading, so it can say it is alive but not ready yet
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout.Duration,
+		ReadTimeout:       cfg.HTTP.ReadTimeout.Duration,
+		WriteTimeout:      cfg.HTTP.WriteTimeout.Duration,
+		IdleTimeout:       cfg.HTTP.IdleTimeout.Duration,
+	}
+	if cfg.HTTP.TLSSelfSigned {
+		server.TLSConfig, err = selfSignedTLSConfig()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	// Event streams never go idle, so they are ended for the server to shut down
+	server.RegisterOnShutdown(gameAPI.Close)
+	server.RegisterOnShutdown(scrapeProgress.Close)
+	go func() {
+		var err error
+		if cfg.HTTP.TLS() {
+			log.Println("Listening with TLS on", cfg.HTTP.Addr)
+			// A self-signed certificate is already in the TLS config, and isn't loaded from the files
+			certFile, keyFile := cfg.HTTP.TLSCertFile, cfg.HTTP.TLSKeyFile
+			if cfg.HTTP.TLSSelfSigned {
+				certFile, keyFile = "", ""
+			}
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			log.Println("Listening on", cfg.HTTP.Addr)
+			err = server.ListenAndServe()
+		}
+		if err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+	<-signalChan
+	shutdown(server)
+}
+
+// initialiseTheLobby creates the lobby and starts loading the words in the background. Players can join once
+// there are enough words.
+func initialiseTheLobby() {
+	theLobby = lobby.NewLobby(createGame, cfg.Decks.Default)
+
+	go loadWordsOfTheDay()
 }
 
-func initialiseTheGame() {
-	words := obtainWordsOfTheDay()
-	wordsByType := words.GroupByType()
+// shutdown stops new connections, gives the games in progress time to finish and then closes every websocket
+// with a close code
+func shutdown(server *http.Server) {
+	log.Println("Shutting down")
 
-	theGame = game.NewGame(wordsByType, *targetScore, *optionsPerQuestion, 10*time.Second, 7)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	if err != nil {
+		log.Println("Unable to shut down the HTTP server cleanly:", err)
+	}
+
+	theLobby.Shutdown(cfg.HTTP.ShutdownGrace.Duration)
+	close(closingConnections)
 
-	go theGame.Run()
+	// Websockets aren't tracked by the HTTP server, so wait here for them to say goodbye
+	closed := make(chan struct{})
+	go func() {
+		connections.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		log.Println("Shutdown complete")
+	case <-time.After(cfg.Connection.WriteWait.Duration):
+		log.Println("Gave up waiting for connections to close")
+	}
+}
+
+// handleHealthz says the server is alive, even while it is still loading words
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
 }
 
+// handleReadyz says whether the words are loaded and players can join
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if !isReady() {
+		http.Error(w, "loading words", http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ready")
+}
+
+// whenReady turns requests away until the words are loaded
+func whenReady(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isReady() {
+			http.Error(w, "The server is loading words. Try again soon.", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// webClient returns the web client's files, from http.staticDir if given, or else the copy built into the server
+func webClient() http.FileSystem {
+	if cfg.HTTP.StaticDir != "" {
+		log.Println("Serving the web client from", cfg.HTTP.StaticDir)
+		return http.Dir(cfg.HTTP.StaticDir)
+	}
+	return http.FS(static.Files)
+}
+
+// selfSignedTLSConfig makes a certificate for this machine, signed by itself. It is saved to http.tlsCertFile if
+// given, so clients can be told to trust it.
+func selfSignedTLSConfig() (*tls.Config, error) {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if hostname, err := os.Hostname(); err == nil {
+		hosts = append(hosts, hostname)
+	}
+	if host, _, err := net.SplitHostPort(cfg.HTTP.Addr); err == nil && host != "" {
+		hosts = append(hosts, host)
+	}
+
+	certificate, certPEM, err := security.SelfSignedCertificate(hosts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make a self-signed certificate: %w", err)
+	}
+	if cfg.HTTP.TLSCertFile != "" {
+		err = ioutil.WriteFile(cfg.HTTP.TLSCertFile, certPEM, 0644)
+		if err != nil {
+			return nil, err
+		}
+		log.Println("Saved the self-signed certificate to", cfg.HTTP.TLSCertFile)
+	}
+	log.Println("Using a self-signed certificate for", strings.Join(hosts, ", "))
+	return &tls.Config{Certificates: []tls.Certificate{certificate}}, nil
+}
+
+// loadNameChecker checks names against the configured blocked words, or the built-in ones if there is no list
+func loadNameChecker() (*names.Checker, error) {
+	blockedWords := names.DefaultBlockedWords
+	if cfg.Names.BlockedWordsFile != "" {
+		file, err := os.Open(cfg.Names.BlockedWordsFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		blockedWords, err = names.ReadWordList(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", cfg.Names.BlockedWordsFile, err)
+		}
+	}
+	return names.NewChecker(cfg.Names.MaxLength, blockedWords), nil
+}
+
+// createGame starts a new game for a room, using the words from the room's decks and the room's rules
+func createGame(room string, options lobby.Options) (*game.Game, error) {
+	words, err := deckStore.LoadAll(options.Decks)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := cfg.RulesFor(room)
+	newGame := game.NewGame(words.GroupByType(), rules.TargetScore, rules.OptionsPerQuestion,
+		rules.QuestionDuration.Duration, rules.MaxPlayers)
+	newGame.CountdownDuration = rules.CountdownDuration.Duration
+	newGame.RevealDuration = rules.RevealDuration.Duration
+	newGame.CorrectPoints = rules.CorrectPoints
+	newGame.SpeedPoints = rules.SpeedPoints
+	newGame.MixedTypes = options.MixedTypes
+	newGame.Names = nameChecker
+	newGame.Icons = icons
+
+	// Check a question can be made before anyone joins
+	_, err = newGame.PickWordsForQuestion()
+	if err != nil {
+		return nil, fmt.Errorf("decks %v can't be used: %w", options.Decks, err)
+	}
+
+	go newGame.Run()
+
+	return newGame, nil
+}
+
+// handleNewPlayer connects a player to a room. The room is picked by the "room" query parameter. A new room can
+// pick its decks with the "decks" query parameter and turn on mixed word types with "mixed", eg
+// /game?room=team&decks=jargon,wotd&mixed=true. Players with a session cookie play as their account, and
+// everyone else plays as a guest.
 func handleNewPlayer(w http.ResponseWriter, r *http.Request) {
+	playerAccount, err := accounts.Account(r)
+	if err != nil && err != account.ErrNotLoggedIn {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	loggedIn := err == nil
+
+	query := r.URL.Query()
+	roomName := query.Get("room")
+	if roomName == "" {
+		roomName = lobby.DefaultRoom
+	}
+
+	options := lobby.Options{
+		Decks:      splitList(query.Get("decks")),
+		MixedTypes: cfg.RulesFor(roomName).MixesTypes(),
+	}
+	if mixed, err := strconv.ParseBool(query.Get("mixed")); err == nil {
+		options.MixedTypes = mixed
+	}
+
+	room, err := theLobby.Join(roomName, options)
+	if err == lobby.ErrShuttingDown {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		log.Println("Unable to join room:", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Print("upgrade fail:", err)
 		return
 	}
 	defer conn.Close()
+	connections.Add(1)
+	defer connections.Done()
 
 	// This channel will block this goroutine from exiting. If it closes, the connection will close
 	disconnectChan := make(chan struct{})
 
-	p := player.NewPlayer(conn, disconnectChan, theGame.MessageChan)
+	settings := player.DefaultConnectionSettings()
+	settings.PongWait = cfg.Connection.PongWait.Duration
+	settings.PingPeriod = cfg.Connection.PongWait.Duration * 9 / 10
+	settings.WriteWait = cfg.Connection.WriteWait.Duration
+	settings.MaxMessageSize = cfg.Connection.MaxMessageSize
+	settings.SendQueueSize = cfg.Connection.SendQueueSize
+	settings.OverflowPolicy, _ = player.ParseOverflowPolicy(cfg.Connection.OverflowPolicy)
+	p := player.NewPlayer(conn, disconnectChan, room.Game.MessageChan, settings)
+	p.DetailsRequest = &model.PlayerDetailsReq{Icons: icons.Icons()}
+	if loggedIn {
+		p.Account = playerAccount.Username
+		p.AccountDetails = model.PlayerDetails{Name: playerAccount.Name, Icon: playerAccount.Icon}
+	}
 
 	go p.ReadPump()
 	go p.WritePump()
 
-	<-disconnectChan
+	select {
+	case <-disconnectChan:
+	case <-closingConnections:
+		// Players in a game have already been closed by it. This closes anyone who never joined one.
+		p.CloseGoingAway("The server is shutting down")
+		<-disconnectChan
+	}
 	conn.Close()
 }
 
-func handleStartGame(w http.ResponseWriter, e *http.Request) {
-	theGame.StartChan <- struct{}{}
-	_, err := fmt.Fprint(w, "Game started")
+// checkOrigin lets pages from the server itself and the allowed origins connect, and logs any it turns away
+func checkOrigin(r *http.Request) bool {
+	if !origins.Check(r) {
+		log.Println("Refusing request from origin", r.Header.Get("Origin"))
+		return false
+	}
+	return true
+}
+
+// guardWrites turns away requests that change things when they come from another website's page or from an
+// address making too many of them. Reads are let through.
+func guardWrites(limiter *security.RateLimiter, handler http.Handler) http.Handler {
+	limited := limiter.Limit(handler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		if !checkOrigin(r) {
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
+		limited.ServeHTTP(w, r)
+	})
+}
+
+// handleStartGame starts the game in the room picked by the "room" query parameter. It must be a POST with an
+// "Authorization: Bearer <token>" header, giving either the room host's token or the admin token. A page on
+// another website can't set the header without the server allowing it, so it can't start games for its visitors.
+func handleStartGame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+	room, ok := theLobby.Get(r.URL.Query().Get("room"))
+	if !ok {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		http.Error(w, "The host or admin token is required", http.StatusUnauthorized)
+		return
+	}
+	var err error
+	adminToken := cfg.Security.AdminToken
+	if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+		err = room.Game.StartAsAdmin()
+	} else {
+		err = room.Game.Start(token)
+	}
+	switch err {
+	case nil:
+	case game.ErrNotHost:
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case game.ErrAlreadyStarted, game.ErrNoPlayers:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, err = fmt.Fprint(w, "Game started")
 	if err != nil {
-		panic(err)
+		log.Println("Unable to write the start response:", err)
 	}
 }
 
-func obtainWordsOfTheDay() model.Words {
+// loadWordsOfTheDay loads the words from the cache, or scrapes them if the cache isn't complete. It is meant to
+// be called as a goroutine, so the server can run while the words are scraped.
+func loadWordsOfTheDay() {
 	var myCache cache.Cache
-	if *cacheType == "file" {
-		myCache = cache.NewFileCache(*cacheFile)
+	if cfg.Cache.Type == "file" {
+		myCache = cache.NewFileCache(cfg.Cache.File)
 	} else {
 		fmt.Println("Invalid cache type provided")
 		os.Exit(1)
 	}
 
 	if myCache.SetupRequired() {
-		return scrapeAndPopulateCache(myCache)
+		var alreadyCached model.Words
+		if myCache.Resumable() {
+			alreadyCached = myCache.LoadWordsFromCache()
+			fmt.Println("Resuming an interrupted scrape with", len(alreadyCached), "words already cached")
+		}
+		scrapeAndPopulateCache(myCache, alreadyCached)
 	} else {
-		return myCache.LoadWordsFromCache()
+		publishWords(myCache.LoadWordsFromCache())
+		scrapeProgress.Finish()
+	}
+
+	if !isReady() {
+		log.Println("Not enough words of each type to play. At least", wordsNeededPerType(), "are needed.")
 	}
 }
 
-func scrapeAndPopulateCache(myCache cache.Cache) model.Words {
-	fmt.Println("Scraping words from the web (please wait)")
+func scrapeAndPopulateCache(myCache cache.Cache, alreadyCached model.Words) {
+	fmt.Println("Scraping words from the web. Games can start once there are enough words.")
+
+	sourceNames := cfg.Scraper.Sources
+	var words = make(model.Words, 0, cfg.Cache.Limit*len(sourceNames))
 
-	var words = make(model.Words, 0, *cacheLimit)
+	// Words from an interrupted run are kept, and their pages aren't scraped again
+	alreadyScraped := make(map[string]bool)
+	alreadyHaveWord := make(map[string]bool)
+	for _, word := range alreadyCached {
+		words = append(words, word)
+		alreadyScraped[word.URL] = true
+		alreadyHaveWord[strings.ToLower(word.Word)] = true
+	}
+	scrapeProgress.Add(len(alreadyCached))
+	publishWords(words)
 
 	// Start a producer of words
-	myScraper := scraper.NewMeriamScraper(*cacheLimit)
+	myScraper, err := scraper.NewFromNames(sourceNames, scraper.Options{
+		Limit:          cfg.Cache.Limit,
+		MeriamURL:      scraper.MeriamBaseURL,
+		WordnikURL:     scraper.WordnikBaseURL,
+		WiktionaryDump: cfg.Scraper.WiktionaryDump,
+		WordFile:       cfg.Scraper.WordFile,
+		Crawl: scraper.CrawlSettings{
+			Concurrency:       cfg.Scraper.Concurrency,
+			RequestsPerSecond: cfg.Scraper.RequestsPerSecond,
+			MaxRetries:        cfg.Scraper.MaxRetries,
+			RetryBackoff:      cfg.Scraper.RetryBackoff.Duration,
+			UserAgent:         cfg.Scraper.UserAgent,
+			IgnoreRobotsTxt:   cfg.Scraper.IgnoreRobotsTxt,
+			AlreadyScraped:    alreadyScraped,
+		},
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	incomingWordChannel := myScraper.Scrape()
 
 	// Create a channel that will be used to write words to the cache
 	cacheChannel := myCache.CreateCacheWriter()
 
-	// Start a consumer that will show percentage progress to the user
-	progressChannel := createConsumerThatShowsPercentageComplete(*cacheLimit)
+	// Show percentage progress to the user
+	go showPercentageComplete()
 
-	// Capture the word into an array, and send it onwards to the CSV writer
+	// Capture the word into an array, and send it onwards to the CSV writer. The games get the new words every
+	// so often, rather than with every word.
+	lastPublished := time.Now()
 	for word := range incomingWordChannel {
+		if alreadyHaveWord[strings.ToLower(word.Word)] {
+			continue
+		}
 		words = append(words, word)
 		cacheChannel <- word
-		progressChannel <- true
+		scrapeProgress.Add(1)
+
+		if !isReady() || time.Since(lastPublished) >= publishInterval {
+			publishWords(words)
+			lastPublished = time.Now()
+		}
 	}
 	close(cacheChannel)
-	close(progressChannel)
+	publishWords(words)
+	scrapeProgress.Finish()
+}
+
+// publishWords replaces the words in the word of the day deck, and gives them to the games in rooms using that
+// deck. The server is ready for players once there are enough words of a type to play with.
+func publishWords(words model.Words) {
+	// The scraper keeps appending to words, so the deck gets its own copy
+	words = append(model.Words(nil), words...)
+	deckStore.AddBuiltIn(deck.WordOfTheDay, words)
+
+	for _, room := range theLobby.Rooms() {
+		if !usesDeck(room, deck.WordOfTheDay) {
+			continue
+		}
+		roomWords, err := deckStore.LoadAll(room.Options.Decks)
+		if err != nil {
+			log.Println("Unable to update the words in room", room.Name+":", err)
+			continue
+		}
+		room.Game.UpdateWords(roomWords.GroupByType())
+	}
+
+	if !isReady() && len(words.GroupByType().UsableTypes(wordsNeededPerType())) > 0 {
+		close(ready)
+		log.Println("Ready for players with", len(words), "words")
+	}
+}
 
-	return words
+func usesDeck(room *lobby.Room, deckName string) bool {
+	for _, name := range room.Options.Decks {
+		if name == deckName {
+			return true
+		}
+	}
+	return false
 }
 
-func createConsumerThatShowsPercentageComplete(limit int) chan bool {
-	progressChannel := make(chan bool)
-	countSoFar := 0
-	previousPercentage := 0
+// wordsNeededPerType is how many words a type needs before it is worth playing with
+func wordsNeededPerType() int {
+	if cfg.Scraper.MinWordsPerType < cfg.Rules.OptionsPerQuestion {
+		return cfg.Rules.OptionsPerQuestion
+	}
+	return cfg.Scraper.MinWordsPerType
+}
 
-	go func() {
-		for range progressChannel {
-			countSoFar++
-			currentPercentage := countSoFar * 100 / limit
-			// Only update the value if there is a change, to minimise flickering
-			if currentPercentage != previousPercentage {
-				fmt.Printf("\r%5v%%", currentPercentage)
-			}
-			previousPercentage = currentPercentage
+func isReady() bool {
+	select {
+	case <-ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// showPercentageComplete prints the scrape progress until the scrape finishes
+func showPercentageComplete() {
+	updates, stop := scrapeProgress.Watch()
+	defer stop()
+
+	previousPercentage := -1
+	for progress := range updates {
+		// Only update the value if there is a change, to minimise flickering
+		if progress.Percent != previousPercentage {
+			fmt.Printf("\r%5v%%", progress.Percent)
 		}
-		fmt.Println()
-	}()
+		previousPercentage = progress.Percent
+	}
+	fmt.Println()
+}
 
-	return progressChannel
+// splitList splits a comma separated list, ignoring blank entries
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,94 @@
+// Command fetch downloads the libraries and fonts the web client would otherwise load from CDNs into static/lib,
+// so the game works on a network without internet access. Run it with "go generate ./static", then rebuild the
+// server to embed them.
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// bootstrapURL is the Bootstrap stylesheet, and bootstrapIntegrity its subresource integrity hash
+const (
+	bootstrapURL       = "https://maxcdn.bootstrapcdn.com/bootstrap/4.0.0/css/bootstrap.min.css"
+	bootstrapIntegrity = "sha384-Gn5384xqQ1aoWXA+058RXPxPg6fy4IWvTNh0E263XmFcJlSAwiGgFAW/dAiS6JXm"
+)
+
+// fontsURL is the Google Fonts stylesheet for the fonts the page uses. The font files it refers to are downloaded
+// too, and the stylesheet changed to load them from lib/fonts.
+const fontsURL = "https://fonts.googleapis.com/css?family=Sancreek|Roboto&display=swap"
+
+// userAgent is a browser's, since Google Fonts picks the font format from it. Browsers that can't use WOFF2
+// fall back to the page's other fonts.
+const userAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+var fontURLPattern = regexp.MustCompile(`url\((https://[^)]+)\)`)
+
+func main() {
+	log.SetFlags(0)
+
+	bootstrap, err := download(bootstrapURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	hash := sha512.Sum384(bootstrap)
+	if integrity := "sha384-" + base64.StdEncoding.EncodeToString(hash[:]); integrity != bootstrapIntegrity {
+		log.Fatalf("%s has integrity %s and expected %s", bootstrapURL, integrity, bootstrapIntegrity)
+	}
+	save(filepath.Join("lib", "bootstrap", "bootstrap-4.0.0.min.css"), bootstrap)
+
+	fonts, err := download(fontsURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	stylesheet := string(fonts)
+	for _, match := range fontURLPattern.FindAllStringSubmatch(stylesheet, -1) {
+		fontURL := match[1]
+		font, err := download(fontURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// The file names are hashes of the font, so they don't clash
+		name := path.Base(fontURL)
+		save(filepath.Join("lib", "fonts", name), font)
+		stylesheet = strings.Replace(stylesheet, fontURL, name, -1)
+	}
+	save(filepath.Join("lib", "fonts", "fonts.css"), []byte(stylesheet))
+}
+
+func download(url string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", userAgent)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to download %s: %s", url, response.Status)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+func save(file string, contents []byte) {
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(file, contents, 0644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Saved", file)
+}
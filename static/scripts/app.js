@@ -1,6 +1,9 @@
 const API_IP = location.host;
 
 var snd = new Audio('./bugle.wav');
+
+// Answers carry the ID of the question they answer, so a late answer isn't counted against the next question
+var currentQuestionId = 0;
 var victory = new Audio('./victory.mp3');
 
 $(document).ready(function () {
@@ -11,9 +14,42 @@ $(document).ready(function () {
         window.location.reload(true);
     });
 
-    $('img.horse-option').click(function () {
-        $('.horse-selected').removeClass('horse-selected'); // removes the previous selected class
-        $(this).addClass('horse-selected'); // adds the class to the clicked image
+    // The icons are added once the server sends them, so listen on their container
+    $('#icon-options').on('click', 'img.horse-option', function () {
+        selectIcon($(this).data('icon'));
+    });
+
+    // Uploaded avatars are added to the icons and picked
+    $('#avatarUpload').on('change', function () {
+        const file = this.files[0];
+        if (!file) {
+            return
+        }
+        $.ajax({
+            url: "/api/icons",
+            method: "POST",
+            data: file,
+            processData: false,
+            contentType: file.type,
+            dataType: "json"
+        }).done(function (icon) {
+            $('#errorBox').hide();
+            addIcon(icon);
+            selectIcon(icon.ID);
+        }).fail(function (xhr) {
+            $('#errorBox').show();
+            $('#errorMessage').text(xhr.responseText || "The avatar couldn't be uploaded.");
+        });
+    });
+
+    // Players who are logged in start with their account's name and horse
+    $.getJSON("/account/me", function (profile) {
+        $('#nameEntryOne').val(profile.Name);
+        accountIcon = profile.Icon;
+        if (accountIcon.startsWith('avatar-')) {
+            addIcon({ID: accountIcon, URL: iconUrl(accountIcon)});
+        }
+        selectIcon(accountIcon);
     });
 
     $('.definition').click(function () {
@@ -22,6 +58,7 @@ $(document).ready(function () {
         const response = $(this).data('option')
         let message = {
             PlayerResponse: {
+                QuestionID: currentQuestionId,
                 Response: response
             }
         };
@@ -31,16 +68,18 @@ $(document).ready(function () {
 
     // Initialises game with players' chosen preferences
     $('.submit').on('click', function () {
-        if (!document.getElementById("nameEntryOne").value || $('.horse-selected')[0].id == undefined) {
+        const selected = $('.horse-selected');
+        if (!document.getElementById("nameEntryOne").value || selected.length === 0) {
             return
         }
 
         $('#selections').hide();
+        $('#errorBox').hide();
 
         let player = {
             PlayerDetailsResp: {
                 Name: document.getElementById("nameEntryOne").value,
-                Icon: $('.horse-selected')[0].id
+                Icon: selected.data('icon')
             }
         };
         connection.send(JSON.stringify(player))
@@ -48,7 +87,12 @@ $(document).ready(function () {
     });
 
     $('#start-game-btn').on('click', function (e) {
-        $.get("http://" + API_IP + "/start", function () {
+        // The host token is sent in a header, which pages on other websites can't do
+        $.ajax({
+            url: "/start" + location.search,
+            method: "POST",
+            headers: {Authorization: "Bearer " + hostToken}
+        }).done(function () {
             console.log("game started");
         });
         $('#startGameBox').hide()
@@ -58,6 +102,57 @@ $(document).ready(function () {
 
 
 //Helper Functions
+
+// The icon from the player's account, picked once the icons arrive
+var accountIcon = "";
+
+// targetScore is the score that wins the room's game, from the Welcome
+var targetScore = 500;
+
+// hostToken lets the player start the game. Only the room's host has one.
+var hostToken = "";
+
+// becomeHost shows the host the button to start the game
+function becomeHost(token) {
+    hostToken = token;
+    $('#waiting-for-host').hide();
+    $('#host-controls').show();
+}
+
+// iconUrl is where the server serves any icon's image, built-in or uploaded
+function iconUrl(id) {
+    return "/api/icons/" + encodeURIComponent(id);
+}
+
+// addIcon adds an icon to the ones the player can pick from, unless it's already there
+function addIcon(icon) {
+    if (iconOption(icon.ID).length > 0) {
+        return
+    }
+    $('<img class="horse-option">')
+        .attr('src', icon.URL)
+        .data('icon', icon.ID)
+        .appendTo('#icon-options');
+}
+
+// showIcons lists the icons the server offers
+function showIcons(icons) {
+    (icons || []).forEach(addIcon);
+    if (accountIcon) {
+        selectIcon(accountIcon);
+    }
+}
+
+function selectIcon(id) {
+    $('.horse-selected').removeClass('horse-selected'); // removes the previous selected class
+    iconOption(id).addClass('horse-selected'); // adds the class to the picked image
+}
+
+function iconOption(id) {
+    return $('#icon-options img.horse-option').filter(function () {
+        return $(this).data('icon') === id;
+    });
+}
 function displayWinner(win, pic) {
     $('#whoWon').show();
     victory.play();
@@ -69,7 +164,23 @@ function displayWinner(win, pic) {
 
 //Variables to initialize
 window.WebSocket = window.WebSocket || window.MozWebSocket;
-var connection = new WebSocket('ws://' + API_IP + '/game');
+// The page's query string picks the room and decks, eg ?room=team&decks=jargon
+// Pages served over HTTPS connect with TLS too
+const WS_SCHEME = location.protocol === 'https:' ? 'wss://' : 'ws://';
+var connection = new WebSocket(WS_SCHEME + API_IP + '/game' + location.search);
+
+// The protocol version this page speaks, and the kinds of message it handles in onmessage
+const PROTOCOL_VERSION = 2;
+const CAPABILITIES = ['PlayerDetailsReq', 'Welcome', 'Host', 'Error', 'AboutToStart', 'PresentQuestion', 'RoundSummary', 'Summary', 'PlayerResult'];
+
+connection.onopen = function () {
+    connection.send(JSON.stringify({
+        Hello: {
+            Version: PROTOCOL_VERSION,
+            Capabilities: CAPABILITIES
+        }
+    }));
+};
 
 connection.onerror = function (error) {
     console.log(error);
@@ -99,6 +210,7 @@ var showCountdown = function () {
 };
 
 var showQuestion = function (question) {
+    currentQuestionId = question.QuestionID;
     let definitions = $('.definition')
     definitions.removeClass('alt-selected'); // removes the previous selected class
     definitions.css('background-color', 'white')
@@ -118,9 +230,9 @@ var updateGame = function (summary) {
         const player = summary.PlayerStates[i];
         const name = player.Name;
 
-        let horseIcon = player.Icon;
+        let horseSrc = iconUrl(player.Icon);
         if (!player.Active) {
-            horseIcon = "dead"
+            horseSrc = "images/dead.png"
         }
 
         let track = $('#track' + i)
@@ -146,12 +258,11 @@ var updateGame = function (summary) {
 
         // Set the horse icon
         const horse = $("#horse" + i)
-        horse.attr('src', 'images/' + horseIcon + '.png')
+        horse.attr('src', horseSrc)
 
         // Set the horse position
-        const targetPoints = 500;
         const maxPosition = 100;
-        let position = Math.floor(player.Score / targetPoints * maxPosition);
+        let position = Math.floor(player.Score / targetScore * maxPosition);
         position = Math.min(position, maxPosition);
 
         horse.animate({left: position + "%"}, "slow");
@@ -160,12 +271,35 @@ var updateGame = function (summary) {
 
 var endGame = function (summary) {
     $('#question-area').hide()
-    displayWinner(summary.Winner, "images/" + summary.Icon + ".png")
+    displayWinner(summary.Winner, iconUrl(summary.Icon))
 };
 
-var showError = function (message) {
+// showError explains what went wrong, using the error's Code to say what the player can do about it
+var showError = function (error) {
+    let text = error.Message;
+    switch (error.Code) {
+        case 'game-in-progress':
+            text = 'A game is already being played in this room.';
+            if (error.RetryAfter) {
+                text += ' It should finish in about ' + error.RetryAfter + ' seconds.';
+            }
+            text += ' Refresh the page to try again, or pick another room.';
+            break;
+        case 'shutting-down':
+            text = 'The server is shutting down. Refresh the page once it is back up.';
+            break;
+        case 'unsupported-version':
+            text = 'This page is out of date. Refresh it to get the latest version.';
+            break;
+        case 'invalid-name':
+        case 'invalid-icon':
+            // Let the player pick again
+            $('#startGameBox').hide();
+            $('#selections').show();
+            break;
+    }
     $('#errorBox').show()
-    $('#errorMessage').text(message.Message)
+    $('#errorMessage').text(text)
 }
 
 // showResult lets the player know which answer was correct
@@ -185,11 +319,24 @@ connection.onmessage = function (wsMessage) {
         console.log("Received: " + wsMessage.data);
         let data = JSON.parse(wsMessage.data);
 
-        if (data.hasOwnProperty('Welcome')) {
+        if (data.hasOwnProperty('PlayerDetailsReq')) {
+            showIcons(data.PlayerDetailsReq.Icons)
+
+        } else if (data.hasOwnProperty('Welcome')) {
             // todo: should display "waiting for other players". Can display target score?
+            targetScore = data.Welcome.TargetScore
+            if (data.Welcome.HostToken) {
+                becomeHost(data.Welcome.HostToken)
+            }
+
+        } else if (data.hasOwnProperty('Host')) {
+            becomeHost(data.Host.Token)
 
         } else if (data.hasOwnProperty('Error')) {
-            showError(data.Error)
+            // An answer that missed the question's time limit is not worth interrupting the player for
+            if (data.Error.Code !== 'question-closed') {
+                showError(data.Error)
+            }
 
         } else if (data.hasOwnProperty('AboutToStart')) {
             showCountdown(data.AboutToStart)
@@ -206,6 +353,7 @@ connection.onmessage = function (wsMessage) {
         } else if (data.hasOwnProperty('PlayerResult')) {
             showResult(data.PlayerResult)
         }
+        // Any other kind of message, including the server's Hello, is ignored
 
     } catch (e) {
         console.log(e);
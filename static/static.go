@@ -0,0 +1,12 @@
+// Package static holds the web client, which is built into the server so it can run from any directory
+package static
+
+import "embed"
+
+//go:generate go run ./fetch
+
+// Files are the web client's pages, scripts, styles, images and sounds, along with the libraries and fonts in lib
+// that would otherwise come from CDNs
+//
+//go:embed index.html favicon.ico *.wav *.mp3 images scripts styles lib
+var Files embed.FS
@@ -0,0 +1,15 @@
+package static
+
+import (
+	"io/fs"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	for _, name := range []string{"index.html", "scripts/app.js", "styles/styles.css", "images/Horse1.png",
+		"bugle.wav", "lib/jquery/jquery-3.6.1.min.js"} {
+		if _, err := fs.Stat(Files, name); err != nil {
+			t.Errorf("Got %v and expected %s to be embedded", err, name)
+		}
+	}
+}